@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// tenantURL returns the URL of an endpoint of the tenant's API.
+func tenantURL(tenant, path string) string {
+	return fmt.Sprintf("https://%s.antithesis.com/api/v1/%s", tenant, path)
+}
+
+// verifyCredentials checks that the tenant accepts the given credentials.
+func verifyCredentials(c HTTPClient, creds credentials) error {
+	req, err := http.NewRequest("GET", tenantURL(creds.Tenant, "auth/verify"), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.SetBasicAuth(creds.Username, creds.Password)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// getJSON sends an authenticated GET request to an endpoint of the tenant's
+// API and decodes its JSON response into v.
+func getJSON(c HTTPClient, creds credentials, path string, query url.Values, v any) error {
+	body, err := get(c, creds, path, query, "application/json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get sends an authenticated GET request to an endpoint of the tenant's API,
+// accepting the given media type, and returns the response body.
+func get(c HTTPClient, creds credentials, path string, query url.Values, accept string) ([]byte, error) {
+	resp, err := download(c, creds, path, query, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// download sends an authenticated GET request to an endpoint of the tenant's
+// API, accepting the given media type. The caller must close the body of the
+// successful response, e.g. once it is done streaming it.
+func download(c HTTPClient, creds credentials, path string, query url.Values, accept string) (*http.Response, error) {
+	u := tenantURL(creds.Tenant, path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.SetBasicAuth(creds.Username, creds.Password)
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// postJSON sends an authenticated POST request with a JSON body, if any, to
+// an endpoint of the tenant's API and decodes its JSON response into v, if
+// not nil.
+func postJSON(c HTTPClient, creds credentials, path string, body any, v any) error {
+	resp, err := post(c, creds, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// post sends an authenticated POST request with a JSON body, if any, to an
+// endpoint of the tenant's API. The caller must close the body of the
+// successful response, e.g. once it is done streaming it.
+func post(c HTTPClient, creds credentials, path string, body any) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %v", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest("POST", tenantURL(creds.Tenant, path), r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.SetBasicAuth(creds.Username, creds.Password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// responseError turns an unsuccessful response into an error, including the
+// message sent by the server when there is one.
+func responseError(resp *http.Response) error {
+	var err error
+	switch resp.StatusCode {
+	case 401, 403:
+		err = fmt.Errorf("access forbidden (HTTP %d): please verify your tenant, username, and password are correct", resp.StatusCode)
+	default:
+		err = fmt.Errorf("unexpected non-200 status code: %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if msg := serverMessage(body); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
+
+// serverMessage extracts the error message of a response body, which is
+// either a JSON object or plain text.
+func serverMessage(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+	apiError := struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	}{}
+	if err := json.Unmarshal(body, &apiError); err == nil {
+		for _, msg := range []string{apiError.Error, apiError.Message, apiError.Detail} {
+			if msg != "" {
+				return msg
+			}
+		}
+		return ""
+	}
+	// Ignore HTML error pages, which are not meant for the terminal.
+	if bytes.HasPrefix(body, []byte("<")) {
+		return ""
+	}
+	return string(body)
+}
@@ -1,21 +1,362 @@
 package cli
 
-import "github.com/spf13/cobra"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
 
-func authCommand() *cobra.Command {
-	return &cobra.Command{
-		Hidden:  true,
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+	"golang.org/x/term"
+)
+
+func authCommand(c HTTPClient) *cobra.Command {
+	cmd := &cobra.Command{
 		Use:     "auth",
 		Long:    "Authenticate with Antithesis",
 		Short:   "Authenticate with Antithesis",
 		GroupID: "management",
 		Example: `
-# Authenticate with Antithesis 
-antithesis auth [login | logout | whoami]		
+# Authenticate with Antithesis
+antithesis auth [login | logout | whoami | profiles]
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(authLoginCommand(c))
+	cmd.AddCommand(authLogoutCommand())
+	cmd.AddCommand(authWhoamiCommand(c))
+	cmd.AddCommand(authProfilesCommand())
+
+	return cmd
+}
+
+func authLoginCommand(c HTTPClient) *cobra.Command {
+	var (
+		tenant   string
+		username string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Long:  "Log in to an Antithesis tenant. The credentials are validated against the tenant and stored so that other commands no longer need --tenant, --username and --password. The password is kept in the OS keyring when available, or in the credential store selected with --store, ANTITHESIS_CREDENTIALS_STORE or the credentials.store config key.",
+		Short: "Log in to an Antithesis tenant",
+		Example: `
+# Log in interactively
+antithesis auth login
+
+# Log in to a specific tenant
+antithesis auth login --tenant='tenant' --username='username'
+
+# Log in to a tenant under a named profile
+antithesis --profile=staging auth login --tenant='tenant-staging'
+
+# Log in on a headless machine without an OS keyring
+ANTITHESIS_CREDENTIALS_PASSPHRASE='passphrase' antithesis auth login --store=encrypted-file
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			resolver, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			profiles, err := loadProfiles()
+			if err != nil {
+				return err
+			}
+			profileName := resolver.Value("default.profile")
+			if p, ok := profiles.Profiles[profileName]; ok {
+				if tenant == "" {
+					tenant = p.Tenant
+				}
+				if username == "" {
+					username = p.Username
+				}
+			}
+
+			r := bufio.NewReader(cmd.InOrStdin())
+			if tenant == "" {
+				if tenant, err = prompt(cmd, r, "Tenant: "); err != nil {
+					return err
+				}
+			}
+			if username == "" {
+				if username, err = prompt(cmd, r, "Username: "); err != nil {
+					return err
+				}
+			}
+			password, err := promptPassword(cmd, r, "Password: ")
+			if err != nil {
+				return err
+			}
+
+			creds := credentials{Tenant: tenant, Username: username, Password: password}
+			if creds.Tenant == "" || creds.Username == "" || creds.Password == "" {
+				return fmt.Errorf("tenant, username and password can't be empty")
+			}
+			if err := verifyCredentials(c, creds); err != nil {
+				return err
+			}
+
+			f, err := loadCredentials()
+			if err != nil {
+				return err
+			}
+			store := resolver.Value("credentials.store")
+			if store == "" {
+				store = defaultSecretStore()
+				if store == fileStore {
+					cmd.Println(WarningStyle.Render("No OS keyring available, the password will be stored unencrypted in your user config directory."))
+				}
+			}
+			if err := f.set(creds, store); err != nil {
+				return err
+			}
+			if err := f.save(); err != nil {
+				return err
+			}
+			if profileName != "" {
+				profiles.Profiles[profileName] = profile{Tenant: creds.Tenant, Username: creds.Username}
+				if err := profiles.save(); err != nil {
+					return err
+				}
+				cmd.Println(SubtleStyle.Render(fmt.Sprintf("Saved profile '%s'.", profileName)))
+			}
+			cmd.Println(SuccessStyle.Render(fmt.Sprintf("Logged in to tenant '%s' as '%s'", creds.Tenant, creds.Username)))
+			cmd.Println(SubtleStyle.Render(fmt.Sprintf("Password stored in the %s credential store.", store)))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&tenant, "tenant", "t", "", "tenant ID to log in to")
+	cmd.Flags().StringVarP(&username, "username", "u", "", "authentication username")
+	cmd.Flags().StringP("store", "s", "", fmt.Sprintf("credential store keeping the password (%s)", strings.Join(availableStores, ", ")))
+
+	return cmd
+}
+
+func authLogoutCommand() *cobra.Command {
+	var (
+		tenant string
+		all    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Long:  "Log out of an Antithesis tenant by removing its stored credentials. Without flags, the tenant of the selected profile is logged out and the profile deleted, or the default tenant when no profile is selected.",
+		Short: "Log out of an Antithesis tenant",
+		Example: `
+# Log out of the default tenant
+antithesis auth logout
+
+# Log out of a specific tenant
+antithesis auth logout --tenant='tenant'
+
+# Log out of the tenant of a profile and delete it
+antithesis --profile=staging auth logout
+
+# Log out of every tenant
+antithesis auth logout --all
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			f, err := loadCredentials()
+			if err != nil {
+				return err
+			}
+			profiles, err := loadProfiles()
+			if err != nil {
+				return err
+			}
+
+			var removed []string
+			switch {
+			case all:
+				for t := range f.Tenants {
+					if _, err := f.remove(t); err != nil {
+						return err
+					}
+					removed = append(removed, t)
+				}
+				profiles.Profiles = make(map[string]profile)
+			default:
+				resolver, err := newConfigResolver(cmd)
+				if err != nil {
+					return err
+				}
+				if name := resolver.Value("default.profile"); name != "" && tenant == "" {
+					p, err := profiles.lookup(name)
+					if err != nil {
+						return err
+					}
+					tenant = p.Tenant
+					delete(profiles.Profiles, name)
+				}
+				if tenant == "" {
+					tenant = f.Default
+				}
+				ok, err := f.remove(tenant)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return fmt.Errorf("not logged in to tenant %s", ValueStyle.Render(fmt.Sprintf("'%s'", tenant)))
+				}
+				removed = append(removed, tenant)
+			}
+			if err := f.save(); err != nil {
+				return err
+			}
+			if err := profiles.save(); err != nil {
+				return err
+			}
+
+			if len(removed) == 0 {
+				cmd.Println("Not logged in to any tenant.")
+				return nil
+			}
+			slices.Sort(removed)
+			for _, t := range removed {
+				cmd.Println(SuccessStyle.Render(fmt.Sprintf("Logged out of tenant '%s'", t)))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&tenant, "tenant", "t", "", "tenant ID to log out of (defaults to the default tenant)")
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "log out of every tenant")
+	cmd.MarkFlagsMutuallyExclusive("tenant", "all")
+
+	return cmd
+}
+
+func authWhoamiCommand(c HTTPClient) *cobra.Command {
+	var (
+		tenant   string
+		username string
+		password string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Long:  "Print the tenant and username the CLI will use, where they come from (flag, environment variable, profile or stored credentials), and whether the tenant accepts them.",
+		Short: "Print the current tenant and username",
+		Example: `
+# Print the current tenant and username
+antithesis auth whoami
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			resolver, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			creds, sources, err := resolveCredentials(resolver)
+			if err != nil {
+				return err
+			}
+
+			if sources.Profile != "" {
+				cmd.Printf("Profile:  %s\n", ValueStyle.Render(sources.Profile))
+			}
+			cmd.Printf("Tenant:   %s %s\n", ValueStyle.Render(creds.Tenant), SubtleStyle.Render(fmt.Sprintf("(from %s)", sources.Tenant)))
+			cmd.Printf("Username: %s %s\n", ValueStyle.Render(creds.Username), SubtleStyle.Render(fmt.Sprintf("(from %s)", sources.Username)))
+			cmd.Printf("Password: %s %s\n", ValueStyle.Render("********"), SubtleStyle.Render(fmt.Sprintf("(from %s)", sources.Password)))
+
+			return verifyCredentials(c, creds)
+		},
+	}
+
+	cmd.Flags().StringVarP(&tenant, "tenant", "t", "", "tenant ID")
+	cmd.Flags().StringVarP(&username, "username", "u", "", "authentication username")
+	cmd.Flags().StringVarP(&password, "password", "p", "", "authentication password")
+
+	return cmd
+}
+
+func authProfilesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "profiles",
+		Long:  "List the profiles created with 'antithesis auth login --profile'. Select one with the global --profile flag or the ANTITHESIS_PROFILE environment variable.",
+		Short: "List the tenant profiles",
+		Example: `
+# List the tenant profiles
+antithesis auth profiles
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cmd.Printf("NOT IMPLEMENTED.\n")
+			cmd.SilenceUsage = true
+
+			profiles, err := loadProfiles()
+			if err != nil {
+				return err
+			}
+			if len(profiles.Profiles) == 0 {
+				cmd.Println("No profiles, create one with 'antithesis auth login --profile=<name>'.")
+				return nil
+			}
+
+			resolver, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			selected := resolver.Value("default.profile")
+			names := maps.Keys(profiles.Profiles)
+			slices.Sort(names)
+			for _, name := range names {
+				p := profiles.Profiles[name]
+				marker := " "
+				if name == selected {
+					marker = "*"
+				}
+				cmd.Printf("%s %s\t%s\n", marker, ValueStyle.Render(name), SubtleStyle.Render(fmt.Sprintf("tenant '%s', username '%s'", p.Tenant, p.Username)))
+			}
 			return nil
 		},
 	}
 }
+
+// prompt asks for a single line of input.
+func prompt(cmd *cobra.Command, r *bufio.Reader, label string) (string, error) {
+	cmd.Print(label)
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptPassword asks for a secret, without echoing it when reading from a
+// terminal.
+func promptPassword(cmd *cobra.Command, r *bufio.Reader, label string) (string, error) {
+	f, ok := cmd.InOrStdin().(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return prompt(cmd, r, label)
+	}
+	cmd.Print(label)
+	password, err := term.ReadPassword(int(f.Fd()))
+	cmd.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimSpace(string(password)), nil
+}
+
+// confirm asks a question that only 'yes' approves. It fails when there is no
+// answer to read, e.g. when stdin is closed in CI.
+func confirm(cmd *cobra.Command, question string) (bool, error) {
+	cmd.Println(WarningStyle.Render(question))
+	cmd.Printf("Only %s will be accepted to approve.\n", ValueStyle.Render("'yes'"))
+	answer, err := prompt(cmd, bufio.NewReader(cmd.InOrStdin()), "Enter a value: ")
+	if err != nil {
+		return false, err
+	}
+	return strings.ToLower(answer) == "yes", nil
+}
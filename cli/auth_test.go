@@ -0,0 +1,413 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+)
+
+// setUserConfigDir points the user config directory to a temporary directory
+// for the duration of the test.
+func setUserConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	t.Setenv("ANTITHESIS_TENANT", "")
+	t.Setenv("ANTITHESIS_USERNAME", "")
+	t.Setenv("ANTITHESIS_PASSWORD", "")
+	t.Setenv("ANTITHESIS_PROFILE", "")
+	// Never touch the keyring of the machine running the tests.
+	t.Setenv("ANTITHESIS_CREDENTIALS_STORE", fileStore)
+	cfg, err := getUserConfigDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return cfg
+}
+
+// resolveCommandCredentials resolves the credentials cmd would use.
+func resolveCommandCredentials(t *testing.T, cmd *cobra.Command) (credentials, credentialSources, error) {
+	t.Helper()
+	r, err := newConfigResolver(cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resolveCredentials(r)
+}
+
+func TestAuthLoginCommand(t *testing.T) {
+	tcs := []struct {
+		name       string
+		args       []string
+		stdin      string
+		statusCode int
+		store      string
+		wantErr    bool
+	}{
+		{
+			name:       "Prompt for all credentials",
+			stdin:      "tenant\nuser\npass\n",
+			statusCode: 200,
+		},
+		{
+			name:       "Tenant and username from flags",
+			args:       []string{"--tenant=tenant", "--username=user"},
+			stdin:      "pass\n",
+			statusCode: 200,
+		},
+		{
+			name:       "Encrypted file store",
+			args:       []string{"--store=encrypted-file"},
+			stdin:      "tenant\nuser\npass\n",
+			statusCode: 200,
+			store:      encryptedFileStore,
+		},
+		{
+			name:       "Unknown store",
+			args:       []string{"--store=unknown"},
+			stdin:      "tenant\nuser\npass\n",
+			statusCode: 200,
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid credentials",
+			stdin:      "tenant\nuser\nwrong\n",
+			statusCode: 403,
+			wantErr:    true,
+		},
+		{
+			name:    "Empty password",
+			args:    []string{"--tenant=tenant", "--username=user"},
+			stdin:   "\n",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := setUserConfigDir(t)
+			t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "passphrase")
+
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: tc.statusCode, Body: io.NopCloser(strings.NewReader(""))}, nil)
+			login := authLoginCommand(mockClient)
+			login.SetIn(strings.NewReader(tc.stdin))
+			login.SetOut(&bytes.Buffer{})
+			login.SetErr(&bytes.Buffer{})
+			login.SetArgs(tc.args)
+
+			err := login.Execute()
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.NoFileExists(t, filepath.Join(cfg, credentialsFileName))
+				return
+			}
+			assert.NoError(t, err)
+
+			info, err := os.Stat(filepath.Join(cfg, credentialsFileName))
+			assert.NoError(t, err)
+			if runtime.GOOS != "windows" {
+				assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+			}
+
+			f, err := loadCredentials()
+			assert.NoError(t, err)
+			creds, ok, err := f.lookup("")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			store := fileStore
+			if tc.store != "" {
+				store = tc.store
+			}
+			assert.Equal(t, credentials{Tenant: "tenant", Username: "user", Password: "pass", Store: store}, creds)
+
+			data, err := os.ReadFile(filepath.Join(cfg, credentialsFileName))
+			assert.NoError(t, err)
+			if store != fileStore {
+				assert.NotContains(t, string(data), "pass")
+			}
+		})
+	}
+}
+
+func TestAuthLogoutCommand(t *testing.T) {
+	tcs := []struct {
+		name      string
+		args      []string
+		remaining []string
+		wantErr   bool
+	}{
+		{
+			name:      "Default tenant",
+			remaining: []string{"staging"},
+		},
+		{
+			name:      "Specific tenant",
+			args:      []string{"--tenant=staging"},
+			remaining: []string{"production"},
+		},
+		{
+			name:      "All tenants",
+			args:      []string{"--all"},
+			remaining: []string{},
+		},
+		{
+			name:      "Unknown tenant",
+			args:      []string{"--tenant=unknown"},
+			remaining: []string{"production", "staging"},
+			wantErr:   true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			setUserConfigDir(t)
+
+			f, err := loadCredentials()
+			assert.NoError(t, err)
+			assert.NoError(t, f.set(credentials{Tenant: "staging", Username: "user", Password: "pass"}, fileStore))
+			assert.NoError(t, f.set(credentials{Tenant: "production", Username: "user", Password: "pass"}, fileStore))
+			assert.NoError(t, f.save())
+
+			logout := authLogoutCommand()
+			logout.SetOut(&bytes.Buffer{})
+			logout.SetErr(&bytes.Buffer{})
+			logout.SetArgs(tc.args)
+
+			err = logout.Execute()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			f, err = loadCredentials()
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tc.remaining, maps.Keys(f.Tenants))
+		})
+	}
+}
+
+func TestEncryptedStoreWithoutPassphrase(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "passphrase")
+	f, err := loadCredentials()
+	assert.NoError(t, err)
+	assert.NoError(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "pass"}, encryptedFileStore))
+	assert.NoError(t, f.save())
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "")
+
+	t.Run("Explicit password", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_PASSWORD", "other")
+		cmd := &cobra.Command{}
+		addCredentialFlags(cmd)
+		creds, _, err := resolveCommandCredentials(t, cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, credentials{Tenant: "tenant", Username: "user", Password: "other"}, creds)
+	})
+
+	t.Run("Stored password", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		addCredentialFlags(cmd)
+		_, _, err := resolveCommandCredentials(t, cmd)
+		assert.EqualError(t, err, "the encrypted-file credential store requires the ANTITHESIS_CREDENTIALS_PASSPHRASE environment variable")
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		logout := authLogoutCommand()
+		logout.SetOut(&bytes.Buffer{})
+		logout.SetErr(&bytes.Buffer{})
+		logout.SetArgs([]string{})
+		assert.NoError(t, logout.Execute())
+
+		f, err := loadCredentials()
+		assert.NoError(t, err)
+		assert.Empty(t, f.Tenants)
+	})
+}
+
+func TestCredentialsSwitchStore(t *testing.T) {
+	t.Run("Failure keeps the previous password", func(t *testing.T) {
+		setUserConfigDir(t)
+		t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "")
+		f, err := loadCredentials()
+		assert.NoError(t, err)
+		assert.NoError(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "pass"}, fileStore))
+		assert.NoError(t, f.save())
+
+		assert.Error(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "new"}, encryptedFileStore))
+		creds, ok, err := f.lookup("tenant")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, credentials{Tenant: "tenant", Username: "user", Password: "pass", Store: fileStore}, creds)
+	})
+
+	t.Run("Previous password deleted once saved", func(t *testing.T) {
+		setUserConfigDir(t)
+		t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "passphrase")
+		f, err := loadCredentials()
+		assert.NoError(t, err)
+		assert.NoError(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "pass"}, encryptedFileStore))
+		assert.NoError(t, f.save())
+
+		assert.NoError(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "new"}, fileStore))
+		encrypted, err := newEncryptedSecretStore()
+		assert.NoError(t, err)
+		password, err := encrypted.Get("tenant")
+		assert.NoError(t, err)
+		assert.Equal(t, "pass", password, "the password must be kept until the credentials file is saved")
+
+		assert.NoError(t, f.save())
+		_, err = encrypted.Get("tenant")
+		assert.ErrorIs(t, err, errSecretNotFound)
+		f, err = loadCredentials()
+		assert.NoError(t, err)
+		creds, ok, err := f.lookup("tenant")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, credentials{Tenant: "tenant", Username: "user", Password: "new", Store: fileStore}, creds)
+	})
+}
+
+func TestAuthWhoamiCommand(t *testing.T) {
+	tcs := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		statusCode int
+		expected   []string
+		wantErr    bool
+	}{
+		{
+			name:       "Stored credentials",
+			statusCode: 200,
+			expected:   []string{"Tenant:   tenant (from ", credentialsFileName, "Username: user (from "},
+		},
+		{
+			name:       "Flags and environment variables",
+			args:       []string{"--tenant=other"},
+			env:        map[string]string{"ANTITHESIS_USERNAME": "envuser", "ANTITHESIS_PASSWORD": "envpass"},
+			statusCode: 200,
+			expected: []string{
+				"Tenant:   other (from flag --tenant)",
+				"Username: envuser (from environment variable ANTITHESIS_USERNAME)",
+				"Password: ******** (from environment variable ANTITHESIS_PASSWORD)",
+			},
+		},
+		{
+			name:       "Rejected credentials",
+			statusCode: 403,
+			wantErr:    true,
+		},
+		{
+			name:    "Unknown tenant",
+			args:    []string{"--tenant=unknown"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			setUserConfigDir(t)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			f, err := loadCredentials()
+			assert.NoError(t, err)
+			assert.NoError(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "pass"}, fileStore))
+			assert.NoError(t, f.save())
+
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: tc.statusCode, Body: io.NopCloser(strings.NewReader(""))}, nil)
+			whoami := authWhoamiCommand(mockClient)
+			stdout := &bytes.Buffer{}
+			whoami.SetOut(stdout)
+			whoami.SetErr(&bytes.Buffer{})
+			whoami.SetArgs(tc.args)
+
+			err = whoami.Execute()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, expected := range tc.expected {
+				assert.Contains(t, stdout.String(), expected)
+			}
+		})
+	}
+}
+
+func TestAuthProfiles(t *testing.T) {
+	setUserConfigDir(t)
+	mockClient := NewMockHttpClient(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+
+	for _, login := range []struct{ profile, tenant string }{
+		{"staging", "tenant-staging"},
+		{"production", "tenant-production"},
+	} {
+		t.Setenv("ANTITHESIS_PROFILE", login.profile)
+		cmd := authLoginCommand(mockClient)
+		cmd.SetIn(strings.NewReader("pass-" + login.profile + "\n"))
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs([]string{"--tenant=" + login.tenant, "--username=user"})
+		assert.NoError(t, cmd.Execute())
+	}
+
+	t.Run("Whoami resolves the selected profile", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_PROFILE", "staging")
+		creds, sources, err := resolveCommandCredentials(t, authWhoamiCommand(mockClient))
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant-staging", creds.Tenant)
+		assert.Equal(t, "pass-staging", creds.Password)
+		assert.Equal(t, "staging (from environment variable ANTITHESIS_PROFILE)", sources.Profile)
+	})
+
+	t.Run("Profile flag wins over the environment", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_PROFILE", "staging")
+		cmd := authWhoamiCommand(mockClient)
+		cmd.Flags().String("profile", "", "")
+		assert.NoError(t, cmd.Flags().Set("profile", "production"))
+		creds, _, err := resolveCommandCredentials(t, cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant-production", creds.Tenant)
+		assert.Equal(t, "pass-production", creds.Password)
+	})
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_PROFILE", "unknown")
+		_, _, err := resolveCommandCredentials(t, authWhoamiCommand(mockClient))
+		assert.Error(t, err)
+	})
+
+	t.Run("List profiles", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_PROFILE", "staging")
+		cmd := authProfilesCommand()
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		assert.NoError(t, cmd.Execute())
+		assert.Equal(t, "  production\ttenant 'tenant-production', username 'user'\n"+
+			"* staging\ttenant 'tenant-staging', username 'user'\n", stdout.String())
+	})
+
+	t.Run("Logout deletes the profile", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_PROFILE", "staging")
+		cmd := authLogoutCommand()
+		cmd.SetOut(&bytes.Buffer{})
+		assert.NoError(t, cmd.Execute())
+
+		profiles, err := loadProfiles()
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"production"}, maps.Keys(profiles.Profiles))
+		f, err := loadCredentials()
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"tenant-production"}, maps.Keys(f.Tenants))
+	})
+}
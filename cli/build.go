@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	dockerEngine = "docker"
+	podmanEngine = "podman"
+
+	// configService is the name of the config image, which holds the
+	// docker-compose.yaml of the project.
+	configService = "config"
+
+	// defaultPlatform is the platform of the images tested by Antithesis.
+	defaultPlatform = "linux/amd64"
+
+	// configDockerfile builds the config image of a config directory that
+	// has no Dockerfile of its own.
+	configDockerfile = "FROM scratch\nCOPY . /\n"
+)
+
+var (
+	availableEngines = []string{dockerEngine, podmanEngine}
+	composeFileNames = []string{"docker-compose.yaml", "docker-compose.yml"}
+)
+
+// CommandRunner runs external programs, such as the Docker or Podman CLI.
+type CommandRunner interface {
+	Run(cmd *exec.Cmd) error
+}
+
+type execRunner struct{}
+
+func (execRunner) Run(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+// projectImage is an image of a project, built from a Dockerfile.
+type projectImage struct {
+	Service string `json:"service" yaml:"service"`
+	// Dockerfile is empty for a config image built without one.
+	Dockerfile string `json:"dockerfile,omitempty" yaml:"dockerfile,omitempty"`
+	Context    string `json:"context" yaml:"context"`
+	Image      string `json:"image" yaml:"image"`
+	Config     bool   `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+type buildResult struct {
+	Tag    string         `json:"tag" yaml:"tag"`
+	Images []projectImage `json:"images" yaml:"images"`
+}
+
+func buildCommand(runner CommandRunner) *cobra.Command {
+	var (
+		tag      string
+		platform string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "build [path]",
+		Long:    "Build the images of a project, such as one created with 'antithesis init'. Every directory with a Dockerfile is built as the image of a service named after the directory, and the config directory holding the docker-compose.yaml is built as the config image. Images are tagged with the git commit of the project by default, under the registry of the tenant of your credentials, the same one 'antithesis push' pushes to.",
+		Short:   "Build the images of a project",
+		GroupID: "development",
+		Example: `
+# Build the images of the project in the current directory
+antithesis build
+
+# Build the images of a project for a registry with a given tag
+antithesis build ./quickstart --registry=docker.io/acme --tag=v1
+
+# Build with Podman
+antithesis build --engine=podman
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+
+			engine, err := containerEngine(r)
+			if err != nil {
+				return err
+			}
+			registry, _, err := projectRegistry(r)
+			if err != nil {
+				// Local builds don't need a tenant.
+				cmd.PrintErrln(WarningStyle.Render(fmt.Sprintf("Warning: images are named without a registry: %v", err)))
+			}
+			result, err := resolveProjectImages(runner, dir, registry, tag)
+			if err != nil {
+				return err
+			}
+			if err := buildImages(cmd, runner, engine, platform, result.Images); err != nil {
+				return err
+			}
+			return printOutput(cmd, output, result, func() error {
+				for _, image := range result.Images {
+					fmt.Fprintln(cmd.OutOrStdout(), image.Image)
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&tag, "tag", "", "tag of the images (defaults to the short git commit of the project)")
+	// The registry and the engine are resolved with the config keys.
+	cmd.Flags().String("registry", "", "registry the images are named after (defaults to the registry of the tenant)")
+	cmd.Flags().String("engine", "", fmt.Sprintf("container engine used to build the images (%s, defaults to the one installed)", strings.Join(availableEngines, ", ")))
+	cmd.Flags().StringVar(&platform, "platform", defaultPlatform, "platform of the images")
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+// resolveProjectImages finds the images of the project in dir and names them
+// after registry and tag, which defaults to the git commit of the project.
+func resolveProjectImages(runner CommandRunner, dir, registry, tag string) (*buildResult, error) {
+	images, err := findProjectImages(dir)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		if tag, err = gitCommit(runner, dir); err != nil {
+			return nil, err
+		}
+	}
+	for i := range images {
+		images[i].Image = imageReference(registry, images[i].Service, tag)
+	}
+	return &buildResult{Tag: tag, Images: images}, nil
+}
+
+// buildImages builds images with engine, with the build output written to
+// stderr.
+func buildImages(cmd *cobra.Command, runner CommandRunner, engine, platform string, images []projectImage) error {
+	for _, image := range images {
+		cmd.PrintErrln(SubtleStyle.Render(fmt.Sprintf("Building %s...", image.Image)))
+
+		args := []string{"build", "--platform", platform, "--tag", image.Image}
+		c := exec.Command(engine, append(args, "--file", "-", image.Context)...)
+		if image.Dockerfile != "" {
+			c = exec.Command(engine, append(args, "--file", image.Dockerfile, image.Context)...)
+		} else {
+			c.Stdin = strings.NewReader(configDockerfile)
+		}
+		c.Stdout = cmd.ErrOrStderr()
+		c.Stderr = cmd.ErrOrStderr()
+		if err := runner.Run(c); err != nil {
+			return fmt.Errorf("failed to build %s: %w", image.Image, err)
+		}
+	}
+	cmd.PrintErrln(SuccessStyle.Render(fmt.Sprintf("Built %d images", len(images))))
+	return nil
+}
+
+// findProjectImages walks dir for the Dockerfiles of the services and the
+// config directory. The config image comes first, followed by the services
+// sorted by name.
+func findProjectImages(dir string) ([]projectImage, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path of %s: %w", dir, err)
+	}
+
+	var images []projectImage
+	var config *projectImage
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+			return filepath.SkipDir
+		}
+
+		image := projectImage{Service: filepath.Base(path), Context: path}
+		if _, err := os.Stat(filepath.Join(path, "Dockerfile")); err == nil {
+			image.Dockerfile = filepath.Join(path, "Dockerfile")
+		}
+		if d.Name() == configService && hasComposeFile(path) {
+			if config != nil {
+				return fmt.Errorf("found several config directories: %s and %s", config.Context, path)
+			}
+			image.Config = true
+			config = &image
+			return nil
+		}
+		if image.Dockerfile != "" {
+			images = append(images, image)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the images of %s: %w", dir, err)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("no config directory with a docker-compose.yaml found in %s", dir)
+	}
+
+	slices.SortStableFunc(images, func(a, b projectImage) int {
+		return strings.Compare(a.Service, b.Service)
+	})
+	images = append([]projectImage{*config}, images...)
+	seen := make(map[string]string, len(images))
+	for _, image := range images {
+		if context, ok := seen[image.Service]; ok {
+			return nil, fmt.Errorf("found several services named %q: %s and %s", image.Service, context, image.Context)
+		}
+		seen[image.Service] = image.Context
+	}
+	return images, nil
+}
+
+func hasComposeFile(dir string) bool {
+	for _, name := range composeFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// containerEngine returns the configured container engine, or the first one
+// installed.
+func containerEngine(r *configResolver) (string, error) {
+	if engine := r.Value("build.engine"); engine != "" {
+		if !slices.Contains(availableEngines, engine) {
+			return "", fmt.Errorf("container engine %q is not supported, available engines are: %s", engine, strings.Join(availableEngines, ", "))
+		}
+		return engine, nil
+	}
+	for _, engine := range availableEngines {
+		if _, err := exec.LookPath(engine); err == nil {
+			return engine, nil
+		}
+	}
+	return "", fmt.Errorf("neither %s is installed, install one or set --engine or the build.engine config key", strings.Join(availableEngines, " nor "))
+}
+
+// gitCommit returns the short commit SHA checked out in dir.
+func gitCommit(runner CommandRunner, dir string) (string, error) {
+	stdout := &bytes.Buffer{}
+	c := exec.Command("git", "rev-parse", "--short", "HEAD")
+	c.Dir = dir
+	c.Stdout = stdout
+	if err := runner.Run(c); err != nil {
+		return "", fmt.Errorf("failed to get the git commit of %s, set --tag instead: %w", dir, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// projectRegistry returns the registry the images of a project are named
+// after: the one set with build.registry, or else the private registry of the
+// tenant of the resolved credentials, which are then returned to log into it.
+func projectRegistry(r *configResolver) (string, *credentials, error) {
+	if registry := r.Value("build.registry"); registry != "" {
+		return registry, nil, nil
+	}
+	creds, _, err := resolveCredentials(r)
+	if err != nil {
+		return "", nil, err
+	}
+	return tenantRegistry(creds.Tenant), &creds, nil
+}
+
+// tenantRegistry returns the private registry of a tenant.
+func tenantRegistry(tenant string) string {
+	return fmt.Sprintf("us-central1-docker.pkg.dev/molten-verve-216720/%s-repository", tenant)
+}
+
+func imageReference(registry, service, tag string) string {
+	if registry == "" {
+		return service + ":" + tag
+	}
+	return strings.TrimSuffix(registry, "/") + "/" + service + ":" + tag
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRunner records the commands it is asked to run instead of running
+// them. git reports a fixed commit, images are inspected as pushed with a
+// digest of sha256:<tag>, under the short names Docker gives to the
+// repositories of Docker Hub, and the commands whose arguments contain fail
+// exit with an error.
+type fakeRunner struct {
+	commands []string
+	stdins   []string
+	fail     string
+}
+
+func (f *fakeRunner) Run(cmd *exec.Cmd) error {
+	f.commands = append(f.commands, strings.Join(cmd.Args, " "))
+	stdin := ""
+	if cmd.Stdin != nil {
+		data, err := io.ReadAll(cmd.Stdin)
+		if err != nil {
+			return err
+		}
+		stdin = string(data)
+	}
+	f.stdins = append(f.stdins, stdin)
+
+	if f.fail != "" && strings.Contains(strings.Join(cmd.Args, " "), f.fail) {
+		return fmt.Errorf("exit status 1")
+	}
+	switch {
+	case cmd.Args[0] == "git":
+		_, err := io.WriteString(cmd.Stdout, "abc1234\n")
+		return err
+	case slices.Contains(cmd.Args, "inspect"):
+		image := cmd.Args[len(cmd.Args)-1]
+		i := strings.LastIndex(image, ":")
+		repository := strings.TrimPrefix(image[:i], "docker.io/")
+		return json.NewEncoder(cmd.Stdout).Encode([]string{"cache@sha256:0", repository + "@sha256:" + image[i+1:]})
+	}
+	return nil
+}
+
+// writeProject creates files, given by slash-separated path, in a temporary
+// project directory.
+func writeProject(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte{}, 0644))
+	}
+	return dir
+}
+
+func TestBuildCommand(t *testing.T) {
+	quickstart := []string{
+		"antithesis/config/docker-compose.yaml",
+		"antithesis/test-template/Dockerfile",
+		"order/Dockerfile",
+		"payment/Dockerfile",
+		"payment/node_modules/left-pad/Dockerfile",
+		".devcontainer/Dockerfile",
+	}
+
+	tcs := []struct {
+		name             string
+		files            []string
+		args             []string
+		tenant           string
+		storedTenant     string
+		fail             string
+		expectedImages   []string
+		expectedCommands []string
+		expectedErr      string
+	}{
+		{
+			name:           "Git commit",
+			files:          quickstart,
+			args:           []string{"--engine=docker"},
+			expectedImages: []string{"config:abc1234", "order:abc1234", "payment:abc1234", "test-template:abc1234"},
+			expectedCommands: []string{
+				"git rev-parse --short HEAD",
+				"docker build --platform linux/amd64 --tag config:abc1234 --file - {dir}/antithesis/config",
+				"docker build --platform linux/amd64 --tag order:abc1234 --file {dir}/order/Dockerfile {dir}/order",
+				"docker build --platform linux/amd64 --tag payment:abc1234 --file {dir}/payment/Dockerfile {dir}/payment",
+				"docker build --platform linux/amd64 --tag test-template:abc1234 --file {dir}/antithesis/test-template/Dockerfile {dir}/antithesis/test-template",
+			},
+		},
+		{
+			name:   "Tenant registry",
+			files:  []string{"config/docker-compose.yml", "config/Dockerfile", "order/Dockerfile"},
+			args:   []string{"--engine=podman", "--tag=v1", "--platform=linux/arm64"},
+			tenant: "acme",
+			expectedImages: []string{
+				"us-central1-docker.pkg.dev/molten-verve-216720/acme-repository/config:v1",
+				"us-central1-docker.pkg.dev/molten-verve-216720/acme-repository/order:v1",
+			},
+			expectedCommands: []string{
+				"podman build --platform linux/arm64 --tag us-central1-docker.pkg.dev/molten-verve-216720/acme-repository/config:v1 --file {dir}/config/Dockerfile {dir}/config",
+				"podman build --platform linux/arm64 --tag us-central1-docker.pkg.dev/molten-verve-216720/acme-repository/order:v1 --file {dir}/order/Dockerfile {dir}/order",
+			},
+		},
+		{
+			name:         "Stored credentials",
+			files:        []string{"config/docker-compose.yaml", "order/Dockerfile"},
+			args:         []string{"--engine=docker", "--tag=v1"},
+			storedTenant: "acme",
+			expectedImages: []string{
+				"us-central1-docker.pkg.dev/molten-verve-216720/acme-repository/config:v1",
+				"us-central1-docker.pkg.dev/molten-verve-216720/acme-repository/order:v1",
+			},
+		},
+		{
+			name:           "Registry",
+			files:          []string{"config/docker-compose.yaml", "order/Dockerfile"},
+			args:           []string{"--engine=docker", "--tag=v1", "--registry=docker.io/acme/"},
+			tenant:         "acme",
+			expectedImages: []string{"docker.io/acme/config:v1", "docker.io/acme/order:v1"},
+		},
+		{
+			name:        "Missing config",
+			files:       []string{"order/Dockerfile"},
+			args:        []string{"--engine=docker", "--tag=v1"},
+			expectedErr: "no config directory with a docker-compose.yaml found in {dir}",
+		},
+		{
+			name:        "Duplicate service",
+			files:       []string{"config/docker-compose.yaml", "order/Dockerfile", "legacy/order/Dockerfile"},
+			args:        []string{"--engine=docker", "--tag=v1"},
+			expectedErr: `found several services named "order": {dir}/legacy/order and {dir}/order`,
+		},
+		{
+			name:        "Unsupported engine",
+			files:       quickstart,
+			args:        []string{"--engine=nerdctl"},
+			expectedErr: "container engine \"nerdctl\" is not supported, available engines are: docker, podman",
+		},
+		{
+			name:        "Build failure",
+			files:       quickstart,
+			args:        []string{"--engine=docker", "--tag=v1"},
+			fail:        "payment",
+			expectedErr: "failed to build payment:v1: exit status 1",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			setUserConfigDir(t)
+			t.Setenv("ANTITHESIS_BUILD_ENGINE", "")
+			t.Setenv("ANTITHESIS_REGISTRY", "")
+			if tc.tenant != "" {
+				t.Setenv("ANTITHESIS_TENANT", tc.tenant)
+				t.Setenv("ANTITHESIS_USERNAME", "user")
+				t.Setenv("ANTITHESIS_PASSWORD", "pass")
+			}
+			if tc.storedTenant != "" {
+				f, err := loadCredentials()
+				assert.NoError(t, err)
+				assert.NoError(t, f.set(credentials{Tenant: tc.storedTenant, Username: "user", Password: "pass"}, fileStore))
+				assert.NoError(t, f.save())
+			}
+			dir := writeProject(t, tc.files...)
+			runner := &fakeRunner{fail: tc.fail}
+			cmd := buildCommand(runner)
+			addGlobalFlags(cmd)
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{dir, "--output=json"}, tc.args...))
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, strings.ReplaceAll(tc.expectedErr, "{dir}", dir))
+				return
+			}
+			assert.NoError(t, err)
+
+			result := buildResult{}
+			assert.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+			var images []string
+			for _, image := range result.Images {
+				images = append(images, image.Image)
+			}
+			assert.Equal(t, tc.expectedImages, images)
+			assert.True(t, result.Images[0].Config)
+			if tc.expectedCommands != nil {
+				for i := range tc.expectedCommands {
+					tc.expectedCommands[i] = strings.ReplaceAll(tc.expectedCommands[i], "{dir}", dir)
+				}
+				assert.Equal(t, tc.expectedCommands, runner.commands)
+			}
+		})
+	}
+
+	t.Run("Config Dockerfile", func(t *testing.T) {
+		setUserConfigDir(t)
+		dir := writeProject(t, "config/docker-compose.yaml")
+		runner := &fakeRunner{}
+		cmd := buildCommand(runner)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{dir, "--engine=docker", "--tag=v1"})
+
+		assert.NoError(t, cmd.Execute())
+		assert.Equal(t, []string{configDockerfile}, runner.stdins)
+		assert.Equal(t, "config:v1\n", stdout.String())
+	})
+}
@@ -1,21 +1,434 @@
 package cli
 
-import "github.com/spf13/cobra"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFileName = "config.yaml"
+)
+
+// configKey describes a setting, which can be set in the config files, with
+// an environment variable and, for some commands, with a flag.
+type configKey struct {
+	Name        string
+	Description string
+	Flag        string
+	Env         string
+	Validate    func(value string) error
+}
+
+var configKeys = []configKey{
+	{
+		Name:        "default.profile",
+		Description: "profile to use",
+		Flag:        "profile",
+		Env:         "ANTITHESIS_PROFILE",
+	},
+	{
+		Name:        "default.tenant",
+		Description: "tenant to use",
+		Flag:        "tenant",
+		Env:         "ANTITHESIS_TENANT",
+	},
+	{
+		Name:        "default.username",
+		Description: "username to authenticate with",
+		Flag:        "username",
+		Env:         "ANTITHESIS_USERNAME",
+	},
+	{
+		Name:        "default.notebook",
+		Description: "notebook executed by 'antithesis run'",
+		Flag:        "notebook",
+		Env:         "ANTITHESIS_NOTEBOOK",
+	},
+	{
+		Name:        "default.duration",
+		Description: "test duration in minutes of 'antithesis run'",
+		Flag:        "duration",
+		Env:         "ANTITHESIS_DURATION",
+		Validate:    validateDuration,
+	},
+	{
+		Name:        "default.email",
+		Description: "comma-separated email addresses notified by 'antithesis run'",
+		Flag:        "email",
+		Env:         "ANTITHESIS_EMAIL",
+		Validate: func(value string) error {
+			return validateEmails(splitList(value))
+		},
+	},
+	{
+		Name:        "default.output",
+		Description: fmt.Sprintf("output format of the commands (%s)", strings.Join(availableOutputs, ", ")),
+		Flag:        "output",
+		Env:         "ANTITHESIS_OUTPUT",
+		Validate:    validateOutput,
+	},
+	{
+		Name:        "credentials.store",
+		Description: fmt.Sprintf("credential store used by 'antithesis auth login' (%s)", strings.Join(availableStores, ", ")),
+		Flag:        "store",
+		Env:         "ANTITHESIS_CREDENTIALS_STORE",
+		Validate: func(value string) error {
+			if !slices.Contains(availableStores, value) {
+				return fmt.Errorf("must be one of: %s", strings.Join(availableStores, ", "))
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "build.engine",
+		Description: fmt.Sprintf("container engine used by 'antithesis build' (%s)", strings.Join(availableEngines, ", ")),
+		Flag:        "engine",
+		Env:         "ANTITHESIS_BUILD_ENGINE",
+		Validate: func(value string) error {
+			if !slices.Contains(availableEngines, value) {
+				return fmt.Errorf("must be one of: %s", strings.Join(availableEngines, ", "))
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "build.registry",
+		Description: "registry the images built by 'antithesis build' are named after",
+		Flag:        "registry",
+		Env:         "ANTITHESIS_REGISTRY",
+	},
+}
 
 func configCommand() *cobra.Command {
-	return &cobra.Command{
-		Hidden:  true,
+	cmd := &cobra.Command{
 		Use:     "config",
-		Long:    "Manage your CLI configuration",
+		Long:    "Manage your CLI configuration. Settings are resolved from, in order of precedence: flags, ANTITHESIS_* environment variables, the project config file (" + projectConfigFileName + ", found by walking up from the working directory) and the user config file. The tenant and username of the selected profile rank just above wherever default.profile is set.\n\nAvailable keys:\n" + configKeysUsage(),
 		Short:   "Manage your CLI configuration",
 		GroupID: "management",
 		Example: `
-# Manage your CLI configuration 
-antithesis config [path | set]		
+# Manage your CLI configuration
+antithesis config [path | get | set | unset | list]
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(configPathCommand())
+	cmd.AddCommand(configGetCommand())
+	cmd.AddCommand(configSetCommand())
+	cmd.AddCommand(configUnsetCommand())
+	cmd.AddCommand(configListCommand())
+
+	return cmd
+}
+
+func configPathCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Long:  "Print the path of the config file",
+		Short: "Print the path of the config file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			path, err := configPath()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+			return nil
+		},
+	}
+}
+
+func configGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Long:  "Print the value of a config key, resolved like the other commands do from the selected profile, the ANTITHESIS_* environment variables, the project config file and the user config file.",
+		Short: "Print the value of a config key",
+		Example: `
+# Print the default tenant
+antithesis config get default.tenant
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if err := validateConfigKey(args[0]); err != nil {
+				return err
+			}
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			value := r.Value(args[0])
+			if value == "" {
+				return fmt.Errorf("%s is not set", ValueStyle.Render(fmt.Sprintf("'%s'", args[0])))
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+}
+
+func configSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Long:  "Set the value of a config key",
+		Short: "Set the value of a config key",
+		Example: `
+# Set the default tenant
+antithesis config set default.tenant tenant
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			cfg, err := loadUserConfig()
+			if err != nil {
+				return err
+			}
+			if err := cfg.set(args[0], args[1]); err != nil {
+				return err
+			}
+			return cfg.save()
+		},
+	}
+}
+
+func configUnsetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Long:  "Remove a config key",
+		Short: "Remove a config key",
+		Example: `
+# Remove the default tenant
+antithesis config unset default.tenant
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			cfg, err := loadUserConfig()
+			if err != nil {
+				return err
+			}
+			// Unknown keys can be removed too, to clean up typos made by hand.
+			if _, ok := cfg.Values[args[0]]; !ok {
+				if err := validateConfigKey(args[0]); err != nil {
+					return err
+				}
+				return nil
+			}
+			delete(cfg.Values, args[0])
+			return cfg.save()
+		},
+	}
+}
+
+func configListCommand() *cobra.Command {
+	var showOrigin bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Long:  "List the config keys that are set, as resolved from the environment and the config files",
+		Short: "List the config keys that are set",
+		Example: `
+# List the config keys that are set and where they come from
+antithesis config list --show-origin
 `,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cmd.Printf("NOT IMPLEMENTED.\n")
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			keys := make(map[string]struct{})
+			for _, layer := range r.layers {
+				for key := range layer.values {
+					keys[key] = struct{}{}
+				}
+			}
+			sorted := maps.Keys(keys)
+			slices.Sort(sorted)
+			for _, key := range sorted {
+				value, origin := r.Get(key)
+				if showOrigin {
+					cmd.Printf("%s\t", SubtleStyle.Render(origin))
+				}
+				cmd.Printf("%s=%s\n", key, value)
+				if err := validateConfigKey(key); err != nil {
+					cmd.Println(WarningStyle.Render(fmt.Sprintf("  %v", err)))
+				}
+			}
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&showOrigin, "show-origin", false, "show where each value comes from")
+
+	return cmd
+}
+
+// userConfig holds the settings of the config file, flattened into dotted
+// keys.
+type userConfig struct {
+	Values map[string]string
+}
+
+func configPath() (string, error) {
+	cfg, err := getUserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(cfg, configFileName), nil
+}
+
+// loadUserConfig reads the config file. A missing file is an empty config.
+func loadUserConfig() (*userConfig, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &userConfig{Values: values}, nil
+}
+
+// readConfigFile reads a YAML config file into dotted keys.
+func readConfigFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	flattenConfig("", tree, values)
+	return values, nil
+}
+
+func (c *userConfig) set(key, value string) error {
+	if err := validateConfigKey(key); err != nil {
+		return err
+	}
+	if validate := lookupConfigKey(key).Validate; validate != nil {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, ValueStyle.Render(fmt.Sprintf("'%s'", key)), err)
+		}
+	}
+	c.Values[key] = value
+	return nil
+}
+
+func (c *userConfig) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	tree := make(map[string]any)
+	for key, value := range c.Values {
+		node := tree
+		parts := strings.Split(key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = value
+	}
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+func flattenConfig(prefix string, tree map[string]any, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if child, ok := value.(map[string]any); ok {
+			flattenConfig(key, child, values)
+			continue
+		}
+		values[key] = fmt.Sprint(value)
+	}
+}
+
+// validateConfigKey rejects keys that are not part of the schema, suggesting
+// the closest known key to catch typos.
+func validateConfigKey(key string) error {
+	if slices.ContainsFunc(configKeys, func(k configKey) bool { return k.Name == key }) {
+		return nil
+	}
+	closest, distance := "", len(key)
+	for _, k := range configKeys {
+		if d := levenshtein(key, k.Name); d < distance {
+			closest, distance = k.Name, d
+		}
+	}
+	if distance <= 3 {
+		return fmt.Errorf("unknown config key %q, did you mean %q?", key, closest)
+	}
+	return fmt.Errorf("unknown config key %q, see 'antithesis config --help' for the available keys", key)
+}
+
+// lookupConfigKey returns the schema of key, which is empty for unknown keys.
+func lookupConfigKey(key string) configKey {
+	i := slices.IndexFunc(configKeys, func(k configKey) bool { return k.Name == key })
+	if i < 0 {
+		return configKey{Name: key}
+	}
+	return configKeys[i]
+}
+
+func configKeysUsage() string {
+	var b strings.Builder
+	for _, k := range configKeys {
+		fmt.Fprintf(&b, "  %-20s %s\n", k.Name, k.Description)
+	}
+	return b.String()
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
 }
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func executeConfigCommand(args ...string) (string, error) {
+	cmd := configCommand()
+	stdout := &bytes.Buffer{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return stdout.String(), err
+}
+
+func TestConfigCommand(t *testing.T) {
+	cfg := setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_CREDENTIALS_STORE", "")
+
+	t.Run("Path", func(t *testing.T) {
+		stdout, err := executeConfigCommand("path")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(cfg, configFileName)+"\n", stdout)
+	})
+
+	t.Run("Set and get", func(t *testing.T) {
+		_, err := executeConfigCommand("set", "default.tenant", "tenant")
+		assert.NoError(t, err)
+		_, err = executeConfigCommand("set", "default.notebook", "basic_test")
+		assert.NoError(t, err)
+
+		stdout, err := executeConfigCommand("get", "default.tenant")
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant\n", stdout)
+
+		data, err := os.ReadFile(filepath.Join(cfg, configFileName))
+		assert.NoError(t, err)
+		assert.Equal(t, "default:\n    notebook: basic_test\n    tenant: tenant\n", string(data))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		stdout, err := executeConfigCommand("list")
+		assert.NoError(t, err)
+		assert.Equal(t, "default.notebook=basic_test\ndefault.tenant=tenant\n", stdout)
+	})
+
+	t.Run("Get resolved value", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_TENANT", "env-tenant")
+		stdout, err := executeConfigCommand("get", "default.tenant")
+		assert.NoError(t, err)
+		assert.Equal(t, "env-tenant\n", stdout)
+	})
+
+	t.Run("Print to stdout", func(t *testing.T) {
+		// Without SetOut, cobra prints to stderr, which $(antithesis config
+		// get) doesn't capture.
+		t.Setenv("ANTITHESIS_TENANT", "env-tenant")
+		for args, expected := range map[string]string{
+			"path":               filepath.Join(cfg, configFileName) + "\n",
+			"get default.tenant": "env-tenant\n",
+		} {
+			r, w, err := os.Pipe()
+			assert.NoError(t, err)
+			stdout := os.Stdout
+			os.Stdout = w
+			cmd := configCommand()
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(strings.Fields(args))
+			err = cmd.Execute()
+			os.Stdout = stdout
+			w.Close()
+			assert.NoError(t, err)
+			data, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(data), "antithesis config %s must print to stdout", args)
+		}
+	})
+
+	t.Run("Unset", func(t *testing.T) {
+		_, err := executeConfigCommand("unset", "default.tenant")
+		assert.NoError(t, err)
+		_, err = executeConfigCommand("get", "default.tenant")
+		assert.Error(t, err)
+	})
+
+	t.Run("Reject unknown keys", func(t *testing.T) {
+		_, err := executeConfigCommand("set", "defualt.tenant", "tenant")
+		assert.EqualError(t, err, `unknown config key "defualt.tenant", did you mean "default.tenant"?`)
+		_, err = executeConfigCommand("get", "something")
+		assert.Error(t, err)
+	})
+
+	t.Run("Reject invalid values", func(t *testing.T) {
+		_, err := executeConfigCommand("set", "default.duration", "forever")
+		assert.Error(t, err)
+		_, err = executeConfigCommand("set", "credentials.store", "vault")
+		assert.Error(t, err)
+	})
+
+	t.Run("Unset unknown keys written by hand", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(cfg, configFileName), []byte("defualt:\n  tenant: tenant\n"), 0644))
+		stdout, err := executeConfigCommand("list")
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, `did you mean "default.tenant"?`)
+
+		_, err = executeConfigCommand("unset", "defualt.tenant")
+		assert.NoError(t, err)
+		stdout, err = executeConfigCommand("list")
+		assert.NoError(t, err)
+		assert.Empty(t, stdout)
+	})
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfg := setUserConfigDir(t)
+
+	f, err := loadCredentials()
+	assert.NoError(t, err)
+	assert.NoError(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "pass"}, fileStore))
+	assert.NoError(t, f.set(credentials{Tenant: "other", Username: "user", Password: "other-pass"}, fileStore))
+	assert.NoError(t, f.save())
+
+	_, err = executeConfigCommand("set", "default.tenant", "tenant")
+	assert.NoError(t, err)
+
+	creds, sources, err := resolveCommandCredentials(t, &cobra.Command{})
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant", creds.Tenant)
+	assert.Equal(t, "pass", creds.Password)
+	assert.Equal(t, "user config "+filepath.Join(cfg, configFileName), sources.Tenant)
+}
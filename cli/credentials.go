@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	credentialsFileName = "credentials.json"
+)
+
+// credentials authenticate requests against a tenant's API. When stored, the
+// password is kept by the secret store named by Store, which defaults to the
+// credentials file itself.
+type credentials struct {
+	Tenant   string `json:"-"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	Store    string `json:"store,omitempty"`
+}
+
+func (c credentials) storeName() string {
+	if c.Store == "" {
+		return fileStore
+	}
+	return c.Store
+}
+
+// credentialsFile is the on-disk representation of every tenant the user has
+// logged in to. It lives in the user config directory and is only readable by
+// its owner.
+type credentialsFile struct {
+	Default string                 `json:"default,omitempty"`
+	Tenants map[string]credentials `json:"tenants"`
+
+	// stale are the tenants whose password moved to another store, to be
+	// deleted from their previous one once the file is saved.
+	stale []credentials
+}
+
+func credentialsPath() (string, error) {
+	cfg, err := getUserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(cfg, credentialsFileName), nil
+}
+
+// loadCredentials reads the credentials file. A missing file is not an error,
+// it simply means the user never logged in.
+func loadCredentials() (*credentialsFile, error) {
+	f := &credentialsFile{Tenants: make(map[string]credentials)}
+	path, err := credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials %s: %w", path, err)
+	}
+	if f.Tenants == nil {
+		f.Tenants = make(map[string]credentials)
+	}
+	return f, nil
+}
+
+// save atomically replaces the credentials file, making sure it is never
+// readable by anyone but its owner.
+func (f *credentialsFile) save() error {
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return err
+	}
+	// The file no longer names the previous stores, so their passwords are
+	// safe to delete.
+	for _, c := range f.stale {
+		store, err := newSecretStore(c.storeName(), f)
+		if err != nil {
+			return err
+		}
+		if err := store.Delete(c.Tenant); err != nil {
+			return fmt.Errorf("failed to delete the previous password of tenant '%s' from the %s credential store: %w", c.Tenant, c.storeName(), err)
+		}
+	}
+	f.stale = nil
+	return nil
+}
+
+// lookup returns the stored credentials of tenant, or of the default tenant
+// when tenant is empty.
+func (f *credentialsFile) lookup(tenant string) (credentials, bool, error) {
+	c, ok := f.entry(tenant)
+	if !ok {
+		return c, false, nil
+	}
+	password, err := f.password(c)
+	if err != nil {
+		return c, false, err
+	}
+	c.Password = password
+	return c, true, nil
+}
+
+// entry returns the stored credentials of tenant, or of the default tenant
+// when tenant is empty, without their password, which is only read from the
+// secret store when needed.
+func (f *credentialsFile) entry(tenant string) (credentials, bool) {
+	if tenant == "" {
+		tenant = f.Default
+	}
+	c, ok := f.Tenants[tenant]
+	if !ok {
+		return credentials{}, false
+	}
+	c.Tenant = tenant
+	c.Password = ""
+	return c, true
+}
+
+// password reads the password of stored credentials from their secret store.
+func (f *credentialsFile) password(c credentials) (string, error) {
+	store, err := newSecretStore(c.storeName(), f)
+	if err != nil {
+		return "", err
+	}
+	password, err := store.Get(c.Tenant)
+	if errors.Is(err, errSecretNotFound) {
+		return "", fmt.Errorf("password of tenant '%s' is missing from the %s credential store, run 'antithesis auth login' again", c.Tenant, c.storeName())
+	}
+	return password, err
+}
+
+// set stores c, keeping its password in the secret store called storeName,
+// and makes its tenant the default one. A password kept by another store
+// before is only deleted from it by save, so that a failure never leaves the
+// tenant without a password.
+func (f *credentialsFile) set(c credentials, storeName string) error {
+	store, err := newSecretStore(storeName, f)
+	if err != nil {
+		return err
+	}
+	old, existed := f.Tenants[c.Tenant]
+	f.Tenants[c.Tenant] = credentials{Username: c.Username, Store: storeName}
+	if err := store.Set(c.Tenant, c.Password); err != nil {
+		if existed {
+			f.Tenants[c.Tenant] = old
+		} else {
+			delete(f.Tenants, c.Tenant)
+		}
+		return err
+	}
+	// The file store keeps the password in the entry replaced above.
+	if existed && old.storeName() != storeName && old.storeName() != fileStore {
+		f.stale = append(f.stale, credentials{Tenant: c.Tenant, Store: old.Store})
+	}
+	f.Default = c.Tenant
+	return nil
+}
+
+// remove forgets the credentials of tenant. It reports whether they existed.
+func (f *credentialsFile) remove(tenant string) (bool, error) {
+	if _, ok := f.Tenants[tenant]; !ok {
+		return false, nil
+	}
+	if err := f.deleteSecret(tenant); err != nil {
+		return false, err
+	}
+	delete(f.Tenants, tenant)
+	if f.Default == tenant {
+		f.Default = ""
+	}
+	return true, nil
+}
+
+func (f *credentialsFile) deleteSecret(tenant string) error {
+	store, err := newSecretStore(f.Tenants[tenant].storeName(), f)
+	if err != nil {
+		return err
+	}
+	return store.Delete(tenant)
+}
+
+// addCredentialFlags adds the flags overriding the credentials of commands
+// calling the tenant's API.
+func addCredentialFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("tenant", "t", "", "tenant ID (defaults to the stored credentials)")
+	cmd.Flags().StringP("username", "u", "", "authentication username (defaults to the stored credentials)")
+	cmd.Flags().StringP("password", "p", "", "authentication password (defaults to the stored credentials)")
+}
+
+// credentialSources describes where each resolved credential came from.
+type credentialSources struct {
+	Profile  string
+	Tenant   string
+	Username string
+	Password string
+}
+
+// resolveCredentials resolves the tenant and username like any other config
+// key, and the password from the --password flag or the ANTITHESIS_PASSWORD
+// environment variable. Whatever is still missing is filled in with the
+// credentials stored by 'antithesis auth login'.
+func resolveCredentials(r *configResolver) (credentials, credentialSources, error) {
+	if r.profileErr != nil {
+		return credentials{}, credentialSources{}, r.profileErr
+	}
+
+	c := credentials{}
+	s := credentialSources{}
+	if name, origin := r.Get("default.profile"); name != "" {
+		s.Profile = fmt.Sprintf("%s (from %s)", name, origin)
+	}
+	c.Tenant, s.Tenant = r.Get("default.tenant")
+	c.Username, s.Username = r.Get("default.username")
+	if f := r.cmd.Flags().Lookup("password"); f != nil && f.Changed {
+		c.Password, s.Password = f.Value.String(), "flag --password"
+	} else if v := os.Getenv("ANTITHESIS_PASSWORD"); v != "" {
+		c.Password, s.Password = v, "environment variable ANTITHESIS_PASSWORD"
+	}
+
+	// A password passed along with a profile is meant for another tenant.
+	if r.profile != "" && c.Password != "" && (s.Tenant == r.profile || s.Username == r.profile) {
+		return c, s, fmt.Errorf("refusing to use the password from %s with the tenant of %s: pass --tenant and --username along with it, or log into the profile with 'antithesis auth login'", s.Password, r.profile)
+	}
+
+	if c.Tenant == "" || c.Username == "" || c.Password == "" {
+		f, err := loadCredentials()
+		if err != nil {
+			return c, s, err
+		}
+		path, err := credentialsPath()
+		if err != nil {
+			return c, s, err
+		}
+		// The secret store is only opened when the password is missing, as
+		// it may not be usable, e.g. without the passphrase it requires.
+		stored, ok := f.entry(c.Tenant)
+		if ok && (c.Username == "" || c.Username == stored.Username) {
+			if c.Tenant == "" {
+				c.Tenant, s.Tenant = stored.Tenant, path
+			}
+			if c.Username == "" {
+				c.Username, s.Username = stored.Username, path
+			}
+			if c.Password == "" {
+				password, err := f.password(stored)
+				if err != nil {
+					return c, s, err
+				}
+				c.Password, s.Password = password, stored.storeName()+" credential store"
+			}
+		}
+	}
+	if c.Tenant == "" || c.Username == "" || c.Password == "" {
+		return c, s, fmt.Errorf("missing credentials: pass --tenant, --username and --password or run 'antithesis auth login'")
+	}
+	return c, s, nil
+}
+
+// writeFileAtomic replaces the file at path with data, so that readers never
+// observe a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
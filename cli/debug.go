@@ -1,21 +1,513 @@
 package cli
 
-import "github.com/spf13/cobra"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
 
-func debugCommand() *cobra.Command {
-	return &cobra.Command{
-		Hidden:  true,
-		Use:     "debug",
-		Long:    "Start multiverse debugging session",
+	"github.com/spf13/cobra"
+)
+
+const (
+	sessionStarting = "starting"
+	sessionReady    = "ready"
+	sessionStopped  = "stopped"
+	sessionFailed   = "failed"
+)
+
+// debugSession is a multiverse debugging session, which lets you explore
+// the environment of a finished run at a given moment.
+type debugSession struct {
+	ID        string    `json:"id" yaml:"id"`
+	RunID     string    `json:"run_id" yaml:"run_id"`
+	Moment    string    `json:"moment" yaml:"moment"`
+	State     string    `json:"state" yaml:"state"`
+	URL       string    `json:"url,omitempty" yaml:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	Message   string    `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// debugBranch is a fork of the timeline of a debugging session, advanced
+// from the moment it was forked at.
+type debugBranch struct {
+	ID        string `json:"id" yaml:"id"`
+	SessionID string `json:"session_id" yaml:"session_id"`
+	Parent    string `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Moment    string `json:"moment" yaml:"moment"`
+}
+
+// execFrame is a frame of the output of a command run in a debugging
+// session. The last frame carries the exit code of the command.
+type execFrame struct {
+	Stream   string `json:"stream,omitempty"`
+	Data     string `json:"data,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// openURL opens a URL in the default browser.
+var openURL = func(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+func debugCommand(c HTTPClient) *cobra.Command {
+	var (
+		moment       string
+		open         bool
+		timeout      time.Duration
+		pollInterval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:     "debug <run-id>",
+		Long:    "Start a multiverse debugging session, to explore the environment of a finished test run at a given moment. The moment is copied from the test report. The command waits for the environment to be ready and prints the URL of the session.",
 		Short:   "Start multiverse debugging session",
 		GroupID: "development",
 		Example: `
-# Start multiverse debugging sessions 
-antithesis debug		
+# Start a multiverse debugging session and open it in the browser
+antithesis debug 3f2a9c --moment='Moment.from({ session_id: "...", input_hash: "...", vtime: 42.1 })' --open
+
+# Manage the active debugging sessions
+antithesis debug [list | stop | exec | branch | cp]
 `,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cmd.Printf("NOT IMPLEMENTED.\n")
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			if moment == "" {
+				return fmt.Errorf(`required flag(s) "moment" not set`)
+			}
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			status, err := fetchRunStatus(c, creds, args[0])
+			if err != nil {
+				return err
+			}
+			if !status.done() {
+				return fmt.Errorf("test run %s is %s, debugging sessions can only be started once it is finished", args[0], status.State)
+			}
+
+			session := &debugSession{}
+			body := map[string]string{"moment": moment}
+			if err := postJSON(c, creds, "runs/"+url.PathEscape(args[0])+"/debug", body, session); err != nil {
+				return err
+			}
+			cmd.PrintErrln(SubtleStyle.Render(fmt.Sprintf("Waiting for debugging session %s to be ready...", session.ID)))
+			session, err = waitForSession(cmd.Context(), c, creds, session, timeout, pollInterval)
+			if err != nil {
+				return err
+			}
+
+			if open && session.URL != "" {
+				if err := openURL(session.URL); err != nil {
+					cmd.PrintErrln(WarningStyle.Render(fmt.Sprintf("Warning: failed to open the browser: %v", err)))
+				}
+			}
+			return printOutput(cmd, output, session, func() error {
+				cmd.Printf("%s\n\n", SuccessStyle.Render(fmt.Sprintf("Debugging session %s is ready!", session.ID)))
+				cmd.Printf("URL: %s\n\n", ValueStyle.Render(session.URL))
+				cmd.Printf("Stop it when you are done with %s\n", ValueStyle.Render(fmt.Sprintf("'antithesis debug stop %s'", session.ID)))
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&moment, "moment", "m", "", "moment of the run to debug, as copied from the test report")
+	cmd.Flags().BoolVar(&open, "open", false, "open the session in the browser once it is ready")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "maximum time to wait for the session to be ready (0 waits forever)")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 5*time.Second, "time between two checks of the session's state")
+	addCredentialFlags(cmd)
+
+	cmd.AddCommand(debugListCommand(c))
+	cmd.AddCommand(debugStopCommand(c))
+	cmd.AddCommand(debugExecCommand(c))
+	cmd.AddCommand(debugBranchCommand(c))
+	cmd.AddCommand(debugCpCommand(c))
+
+	return cmd
+}
+
+func debugListCommand(c HTTPClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Long:  "List the active multiverse debugging sessions of your tenant",
+		Short: "List the active debugging sessions",
+		Example: `
+# List the active debugging sessions
+antithesis debug list
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			list := struct {
+				Sessions []debugSession `json:"sessions" yaml:"sessions"`
+			}{}
+			if err := getJSON(c, creds, "debug", nil, &list); err != nil {
+				return err
+			}
+
+			return printOutput(cmd, output, list.Sessions, func() error {
+				if len(list.Sessions) == 0 {
+					cmd.Println("No active debugging sessions.")
+					return nil
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tRUN\tSTATE\tCREATED\tURL")
+				for _, s := range list.Sessions {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.ID, s.RunID, s.State, s.CreatedAt.Local().Format("Jan 2 3:04PM"), s.URL)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+func debugStopCommand(c HTTPClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stop <session-id>",
+		Long:  "Stop a multiverse debugging session, releasing its environment",
+		Short: "Stop a debugging session",
+		Example: `
+# Stop a debugging session
+antithesis debug stop 9d4c1e
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			if err := postJSON(c, creds, sessionPath(args[0])+"/stop", nil, nil); err != nil {
+				return err
+			}
+			cmd.Println(SuccessStyle.Render(fmt.Sprintf("Debugging session %s has been stopped", args[0])))
 			return nil
 		},
 	}
+
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+func debugExecCommand(c HTTPClient) *cobra.Command {
+	var (
+		container string
+		branch    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "exec <session-id> -- <command> [args...]",
+		Long:  "Run a command in a container of a debugging session, at the moment of the session or of one of its branches, and stream its output. The command exits with the exit code of the command it ran.",
+		Short: "Run a command in a debugging session",
+		Example: `
+# List the files of the database container at the moment of the session
+antithesis debug exec 9d4c1e --container=postgres -- ls -la /var/lib/postgresql
+
+# Run a command in a branch of the session
+antithesis debug exec 9d4c1e --branch=b2 --container=order -- cat /tmp/order.log
+`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if container == "" {
+				return fmt.Errorf(`required flag(s) "container" not set`)
+			}
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			body := map[string]any{
+				"container": container,
+				"command":   args[1:],
+			}
+			if branch != "" {
+				body["branch"] = branch
+			}
+			resp, err := post(c, creds, sessionPath(args[0])+"/exec", body)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			code, err := streamExecOutput(resp.Body, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				return &exitCodeError{code: code, err: fmt.Errorf("command exited with code %d", code)}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&container, "container", "c", "", "container to run the command in")
+	cmd.Flags().StringVarP(&branch, "branch", "b", "", "branch of the session to run the command in (defaults to the moment of the session)")
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+// streamExecOutput copies the output frames of a command to stdout and
+// stderr as they come, and returns its exit code.
+func streamExecOutput(r io.Reader, stdout, stderr io.Writer) (int, error) {
+	dec := json.NewDecoder(r)
+	for {
+		frame := execFrame{}
+		if err := dec.Decode(&frame); err == io.EOF {
+			return 0, fmt.Errorf("the output of the command ended before its exit code")
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to read the output of the command: %w", err)
+		}
+		switch {
+		case frame.Error != "":
+			return 0, fmt.Errorf("failed to run the command: %s", frame.Error)
+		case frame.ExitCode != nil:
+			return *frame.ExitCode, nil
+		case frame.Stream == "stderr":
+			io.WriteString(stderr, frame.Data)
+		default:
+			io.WriteString(stdout, frame.Data)
+		}
+	}
+}
+
+func debugBranchCommand(c HTTPClient) *cobra.Command {
+	var (
+		advance time.Duration
+		from    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "branch <session-id>",
+		Long:  "Fork the timeline of a debugging session and advance the new branch, to see what happens next. Run commands in the branch with 'antithesis debug exec --branch'.",
+		Short: "Fork and advance the timeline of a debugging session",
+		Example: `
+# Fork the timeline at the moment of the session and advance it by 30 seconds
+antithesis debug branch 9d4c1e --advance=30s
+
+# Fork a branch and advance it further
+antithesis debug branch 9d4c1e --from=b2 --advance=5s
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if advance <= 0 {
+				return fmt.Errorf("--advance must be positive")
+			}
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			body := map[string]any{"advance_seconds": advance.Seconds()}
+			if from != "" {
+				body["from"] = from
+			}
+			b := &debugBranch{}
+			if err := postJSON(c, creds, sessionPath(args[0])+"/branch", body, b); err != nil {
+				return err
+			}
+
+			return printOutput(cmd, output, b, func() error {
+				cmd.Printf("%s\n\n", SuccessStyle.Render(fmt.Sprintf("Branch %s has been advanced by %s!", b.ID, advance)))
+				cmd.Printf("Moment: %s\n\n", ValueStyle.Render(b.Moment))
+				cmd.Printf("Run commands in it with %s\n", ValueStyle.Render(fmt.Sprintf("'antithesis debug exec %s --branch=%s --container=<container> -- <command>'", args[0], b.ID)))
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().DurationVarP(&advance, "advance", "a", 0, "how far to advance the new branch, e.g. 30s")
+	cmd.Flags().StringVar(&from, "from", "", "branch to fork (defaults to the moment of the session)")
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+func debugCpCommand(c HTTPClient) *cobra.Command {
+	var branch string
+
+	cmd := &cobra.Command{
+		Use:   "cp <session-id>:<container>:<path> <local-dest>",
+		Long:  "Copy a file or directory out of a container of a debugging session, e.g. a core dump, database files or logs, into a local directory, which is created if needed.",
+		Short: "Copy files out of a debugging session",
+		Example: `
+# Copy the data of the database container into ./postgres
+antithesis debug cp 9d4c1e:postgres:/var/lib/postgresql/data ./postgres
+
+# Copy a core dump out of a branch of the session
+antithesis debug cp 9d4c1e:order:/tmp/core.1234 . --branch=b2
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session, container, path, err := parseSessionPath(args[0])
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			query := url.Values{}
+			query.Set("container", container)
+			query.Set("path", path)
+			if branch != "" {
+				query.Set("branch", branch)
+			}
+			resp, err := download(c, creds, sessionPath(session)+"/files", query, "application/gzip")
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			if err := os.MkdirAll(args[1], 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", args[1], err)
+			}
+			if err := untar(resp.Body, args[1], false); err != nil {
+				return fmt.Errorf("failed to extract files: %w", err)
+			}
+			cmd.Println(SuccessStyle.Render(fmt.Sprintf("Copied %s from container %s to %s", path, container, args[1])))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&branch, "branch", "b", "", "branch of the session to copy from (defaults to the moment of the session)")
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+// parseSessionPath parses a <session-id>:<container>:<path> argument.
+func parseSessionPath(arg string) (string, string, string, error) {
+	parts := strings.SplitN(arg, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid source %q, expected <session-id>:<container>:<path>", arg)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func sessionPath(id string) string {
+	return "debug/" + url.PathEscape(id)
+}
+
+// waitForSession polls the state of a session until it is ready or the
+// timeout, if any, expires.
+func waitForSession(ctx context.Context, c HTTPClient, creds credentials, session *debugSession, timeout, pollInterval time.Duration) (*debugSession, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		switch session.State {
+		case sessionReady:
+			return session, nil
+		case sessionFailed, sessionStopped:
+			err := fmt.Errorf("debugging session %s is %s", session.ID, session.State)
+			if session.Message != "" {
+				err = fmt.Errorf("%w: %s", err, session.Message)
+			}
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, &exitCodeError{
+					code: exitTimeout,
+					err:  fmt.Errorf("timed out after %s waiting for debugging session %s to be ready", timeout, session.ID),
+				}
+			}
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+		latest := &debugSession{}
+		if err := getJSON(c, creds, sessionPath(session.ID), nil, latest); err != nil {
+			return nil, err
+		}
+		session = latest
+	}
 }
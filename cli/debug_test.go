@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDebugAPI serves a finished run-1 and a running run-2. Sessions become
+// ready after two polls.
+type fakeDebugAPI struct {
+	mu      sync.Mutex
+	polls   int
+	moment  string
+	stopped []string
+	execs   []map[string]any
+	files   []string
+}
+
+func (f *fakeDebugAPI) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/runs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		state := runComplete
+		if r.PathValue("id") == "run-2" {
+			state = runRunning
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(runStatus{ID: r.PathValue("id"), State: state}))
+	})
+	mux.HandleFunc("POST /api/v1/runs/run-1/debug", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		f.mu.Lock()
+		f.moment = body["moment"]
+		f.mu.Unlock()
+		assert.NoError(t, json.NewEncoder(w).Encode(debugSession{ID: "session-1", RunID: "run-1", Moment: body["moment"], State: sessionStarting}))
+	})
+	mux.HandleFunc("GET /api/v1/debug/session-1", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.polls++
+		session := debugSession{ID: "session-1", RunID: "run-1", State: sessionStarting}
+		if f.polls >= 2 {
+			session.State = sessionReady
+			session.URL = "https://tenant.antithesis.com/debug/session-1"
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(session))
+	})
+	mux.HandleFunc("GET /api/v1/debug", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]any{"sessions": []debugSession{
+			{ID: "session-1", RunID: "run-1", State: sessionReady, URL: "https://tenant.antithesis.com/debug/session-1"},
+		}}))
+	})
+	mux.HandleFunc("POST /api/v1/debug/{id}/stop", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "session-1" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error": "session not found"}`)
+			return
+		}
+		f.mu.Lock()
+		f.stopped = append(f.stopped, r.PathValue("id"))
+		f.mu.Unlock()
+	})
+	mux.HandleFunc("POST /api/v1/debug/session-1/exec", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		f.mu.Lock()
+		f.execs = append(f.execs, body)
+		f.mu.Unlock()
+
+		enc := json.NewEncoder(w)
+		command := body["command"].([]any)
+		switch command[0] {
+		case "crash":
+			assert.NoError(t, enc.Encode(execFrame{Error: "container crash not found"}))
+			return
+		case "hang":
+			assert.NoError(t, enc.Encode(execFrame{Stream: "stdout", Data: "partial"}))
+			return
+		}
+		assert.NoError(t, enc.Encode(execFrame{Stream: "stdout", Data: "hello\n"}))
+		assert.NoError(t, enc.Encode(execFrame{Stream: "stderr", Data: "warning\n"}))
+		assert.NoError(t, enc.Encode(execFrame{Stream: "stdout", Data: "world\n"}))
+		code := 0
+		if command[0] == "false" {
+			code = 1
+		}
+		assert.NoError(t, enc.Encode(execFrame{ExitCode: &code}))
+	})
+	mux.HandleFunc("POST /api/v1/debug/session-1/branch", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		parent, _ := body["from"].(string)
+		moment := fmt.Sprintf("vtime:%v", 42.1+body["advance_seconds"].(float64))
+		assert.NoError(t, json.NewEncoder(w).Encode(debugBranch{ID: "b1", SessionID: "session-1", Parent: parent, Moment: moment}))
+	})
+	mux.HandleFunc("GET /api/v1/debug/session-1/files", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.files = append(f.files, r.URL.RawQuery)
+		f.mu.Unlock()
+
+		entries := map[string]string{"data/PG_VERSION": "16\n", "data/base/1": "tuples"}
+		if r.URL.Query().Get("path") == "/etc/passwd" {
+			entries = map[string]string{"../passwd": "root"}
+		}
+		gzw := gzip.NewWriter(w)
+		tw := tar.NewWriter(gzw)
+		for _, name := range slices.Sorted(maps.Keys(entries)) {
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(entries[name])), Typeflag: tar.TypeReg}))
+			_, err := tw.Write([]byte(entries[name]))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, gzw.Close())
+	})
+	return mux
+}
+
+func TestDebugCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	var opened []string
+	defaultOpenURL := openURL
+	t.Cleanup(func() { openURL = defaultOpenURL })
+	openURL = func(u string) error {
+		opened = append(opened, u)
+		return nil
+	}
+
+	tcs := []struct {
+		name            string
+		args            []string
+		expectedOutput  []string
+		expectedErr     string
+		expectedOpened  []string
+		expectedMoment  string
+		expectedStopped []string
+	}{
+		{
+			name:           "Start",
+			args:           []string{"run-1", "--moment=vtime:42.1", "--poll-interval=1ms"},
+			expectedOutput: []string{"Debugging session session-1 is ready!", "https://tenant.antithesis.com/debug/session-1", "antithesis debug stop session-1"},
+			expectedMoment: "vtime:42.1",
+		},
+		{
+			name:           "Start and open",
+			args:           []string{"run-1", "--moment=vtime:42.1", "--poll-interval=1ms", "--open"},
+			expectedOpened: []string{"https://tenant.antithesis.com/debug/session-1"},
+			expectedMoment: "vtime:42.1",
+		},
+		{
+			name:        "Missing moment",
+			args:        []string{"run-1"},
+			expectedErr: `required flag(s) "moment" not set`,
+		},
+		{
+			name:        "Run in progress",
+			args:        []string{"run-2", "--moment=vtime:42.1"},
+			expectedErr: "test run run-2 is running, debugging sessions can only be started once it is finished",
+		},
+		{
+			name:        "Timed out",
+			args:        []string{"run-1", "--moment=vtime:42.1", "--poll-interval=1h", "--timeout=10ms"},
+			expectedErr: "timed out after 10ms waiting for debugging session session-1 to be ready",
+		},
+		{
+			name:           "No timeout",
+			args:           []string{"run-1", "--moment=vtime:42.1", "--poll-interval=1ms", "--timeout=0"},
+			expectedOutput: []string{"Debugging session session-1 is ready!"},
+			expectedMoment: "vtime:42.1",
+		},
+		{
+			name:           "List",
+			args:           []string{"list"},
+			expectedOutput: []string{"ID", "session-1", "run-1", sessionReady},
+		},
+		{
+			name:            "Stop",
+			args:            []string{"stop", "session-1"},
+			expectedOutput:  []string{"Debugging session session-1 has been stopped"},
+			expectedStopped: []string{"session-1"},
+		},
+		{
+			name:        "Stop unknown session",
+			args:        []string{"stop", "session-2"},
+			expectedErr: "unexpected non-200 status code: 404: session not found",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			opened = nil
+			api := &fakeDebugAPI{}
+			cmd := debugCommand(newFakeServerClient(t, api.handler(t)))
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range tc.expectedOutput {
+				assert.Contains(t, stdout.String(), s)
+			}
+			assert.Equal(t, tc.expectedOpened, opened)
+			assert.Equal(t, tc.expectedMoment, api.moment)
+			assert.Equal(t, tc.expectedStopped, api.stopped)
+		})
+	}
+
+	t.Run("Start JSON", func(t *testing.T) {
+		cmd := debugCommand(newFakeServerClient(t, (&fakeDebugAPI{}).handler(t)))
+		addGlobalFlags(cmd)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"run-1", "--moment=vtime:42.1", "--poll-interval=1ms", "--output=json"})
+
+		assert.NoError(t, cmd.Execute())
+
+		session := debugSession{}
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &session))
+		assert.Equal(t, sessionReady, session.State)
+		assert.Equal(t, "https://tenant.antithesis.com/debug/session-1", session.URL)
+	})
+}
+
+func TestDebugExecCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	tcs := []struct {
+		name             string
+		args             []string
+		expectedStdout   string
+		expectedStderr   string
+		expectedExec     map[string]any
+		expectedErr      string
+		expectedExitCode int
+	}{
+		{
+			name:           "Success",
+			args:           []string{"exec", "session-1", "--container=postgres", "--", "ls", "-la"},
+			expectedStdout: "hello\nworld\n",
+			expectedStderr: "warning\n",
+			expectedExec:   map[string]any{"container": "postgres", "command": []any{"ls", "-la"}},
+		},
+		{
+			name:           "Branch",
+			args:           []string{"exec", "session-1", "--container=postgres", "--branch=b1", "--", "ls"},
+			expectedStdout: "hello\nworld\n",
+			expectedExec:   map[string]any{"container": "postgres", "branch": "b1", "command": []any{"ls"}},
+		},
+		{
+			name:             "Exit code",
+			args:             []string{"exec", "session-1", "--container=postgres", "--", "false"},
+			expectedStdout:   "hello\nworld\n",
+			expectedErr:      "command exited with code 1",
+			expectedExitCode: 1,
+		},
+		{
+			name:        "Missing container",
+			args:        []string{"exec", "session-1", "--", "ls"},
+			expectedErr: `required flag(s) "container" not set`,
+		},
+		{
+			name:        "Server error",
+			args:        []string{"exec", "session-1", "--container=crash", "--", "crash"},
+			expectedErr: "failed to run the command: container crash not found",
+		},
+		{
+			name:           "Truncated output",
+			args:           []string{"exec", "session-1", "--container=postgres", "--", "hang"},
+			expectedStdout: "partial",
+			expectedErr:    "the output of the command ended before its exit code",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &fakeDebugAPI{}
+			cmd := debugCommand(newFakeServerClient(t, api.handler(t)))
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				if tc.expectedExitCode != 0 {
+					assert.Equal(t, tc.expectedExitCode, ExitCode(err))
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.expectedStdout != "" {
+				assert.Equal(t, tc.expectedStdout, stdout.String())
+			}
+			if tc.expectedStderr != "" {
+				assert.Equal(t, tc.expectedStderr, stderr.String())
+			}
+			if tc.expectedExec != nil {
+				assert.Equal(t, []map[string]any{tc.expectedExec}, api.execs)
+			}
+		})
+	}
+}
+
+func TestDebugBranchCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	tcs := []struct {
+		name           string
+		args           []string
+		expectedBranch debugBranch
+		expectedErr    string
+	}{
+		{
+			name:           "Advance",
+			args:           []string{"branch", "session-1", "--advance=30s"},
+			expectedBranch: debugBranch{ID: "b1", SessionID: "session-1", Moment: "vtime:72.1"},
+		},
+		{
+			name:           "From branch",
+			args:           []string{"branch", "session-1", "--from=b0", "--advance=500ms"},
+			expectedBranch: debugBranch{ID: "b1", SessionID: "session-1", Parent: "b0", Moment: "vtime:42.6"},
+		},
+		{
+			name:        "Missing advance",
+			args:        []string{"branch", "session-1"},
+			expectedErr: "--advance must be positive",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := debugCommand(newFakeServerClient(t, (&fakeDebugAPI{}).handler(t)))
+			addGlobalFlags(cmd)
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append(tc.args, "--output=json"))
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			b := debugBranch{}
+			assert.NoError(t, json.Unmarshal(stdout.Bytes(), &b))
+			assert.Equal(t, tc.expectedBranch, b)
+		})
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		cmd := debugCommand(newFakeServerClient(t, (&fakeDebugAPI{}).handler(t)))
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetArgs([]string{"branch", "session-1", "--advance=30s"})
+
+		assert.NoError(t, cmd.Execute())
+		assert.Contains(t, stdout.String(), "Branch b1 has been advanced by 30s!")
+		assert.Contains(t, stdout.String(), "antithesis debug exec session-1 --branch=b1")
+	})
+}
+
+func TestDebugCpCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	tcs := []struct {
+		name          string
+		args          []string
+		expectedFiles map[string]string
+		expectedQuery string
+		expectedErr   string
+	}{
+		{
+			name:          "Directory",
+			args:          []string{"session-1:postgres:/var/lib/postgresql/data"},
+			expectedFiles: map[string]string{"data/PG_VERSION": "16\n", "data/base/1": "tuples"},
+			expectedQuery: "container=postgres&path=%2Fvar%2Flib%2Fpostgresql%2Fdata",
+		},
+		{
+			name:          "Branch",
+			args:          []string{"session-1:postgres:/var/lib/postgresql/data", "--branch=b1"},
+			expectedFiles: map[string]string{"data/PG_VERSION": "16\n", "data/base/1": "tuples"},
+			expectedQuery: "branch=b1&container=postgres&path=%2Fvar%2Flib%2Fpostgresql%2Fdata",
+		},
+		{
+			name:        "Path outside of the destination",
+			args:        []string{"session-1:postgres:/etc/passwd"},
+			expectedErr: `failed to extract files: invalid path "../passwd" in archive`,
+		},
+		{
+			name:        "Invalid source",
+			args:        []string{"session-1:/var/lib/postgresql/data"},
+			expectedErr: `invalid source "session-1:/var/lib/postgresql/data", expected <session-id>:<container>:<path>`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "dest")
+			api := &fakeDebugAPI{}
+			cmd := debugCommand(newFakeServerClient(t, api.handler(t)))
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"cp", tc.args[0], dest}, tc.args[1:]...))
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for name, content := range tc.expectedFiles {
+				data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(data))
+			}
+			assert.Equal(t, []string{tc.expectedQuery}, api.files)
+			assert.Contains(t, stdout.String(), "Copied")
+		})
+	}
+}
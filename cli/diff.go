@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// propertyAbsent is the status of a property missing from a report.
+	propertyAbsent = "absent"
+)
+
+// reportDiff lists the properties whose outcome changed between a base run
+// and a head run.
+type reportDiff struct {
+	Base         string           `json:"base" yaml:"base"`
+	Head         string           `json:"head" yaml:"head"`
+	NewlyFailing []propertyChange `json:"newly_failing" yaml:"newly_failing"`
+	NewlyPassing []propertyChange `json:"newly_passing" yaml:"newly_passing"`
+	NewlyReached []propertyChange `json:"newly_reached" yaml:"newly_reached"`
+	Disappeared  []propertyChange `json:"disappeared" yaml:"disappeared"`
+}
+
+type propertyChange struct {
+	Name   string `json:"name" yaml:"name"`
+	Before string `json:"before" yaml:"before"`
+	After  string `json:"after" yaml:"after"`
+}
+
+// diffReports compares the outcomes of the properties of two reports, in the
+// order of the head report followed by the properties missing from it.
+func diffReports(base, head *testReport) *reportDiff {
+	d := &reportDiff{
+		Base:         base.RunID,
+		Head:         head.RunID,
+		NewlyFailing: []propertyChange{},
+		NewlyPassing: []propertyChange{},
+		NewlyReached: []propertyChange{},
+		Disappeared:  []propertyChange{},
+	}
+
+	before := make(map[string]string, len(base.Properties))
+	for _, p := range base.Properties {
+		before[p.Name] = p.Status
+	}
+	after := make(map[string]string, len(head.Properties))
+	for _, p := range head.Properties {
+		after[p.Name] = p.Status
+	}
+	reached := func(status string) bool {
+		return status == propertyPassed || status == propertyFailed
+	}
+
+	for _, p := range head.Properties {
+		change := propertyChange{Name: p.Name, Before: propertyAbsent, After: p.Status}
+		if status, ok := before[p.Name]; ok {
+			change.Before = status
+		}
+		switch {
+		case change.After == propertyFailed && change.Before != propertyFailed:
+			d.NewlyFailing = append(d.NewlyFailing, change)
+		case change.After == propertyPassed && change.Before == propertyFailed:
+			d.NewlyPassing = append(d.NewlyPassing, change)
+		case change.After == propertyPassed && !reached(change.Before):
+			d.NewlyReached = append(d.NewlyReached, change)
+		case change.After == propertyUnfound && reached(change.Before):
+			d.Disappeared = append(d.Disappeared, change)
+		}
+	}
+	for _, p := range base.Properties {
+		if _, ok := after[p.Name]; !ok && reached(p.Status) {
+			d.Disappeared = append(d.Disappeared, propertyChange{Name: p.Name, Before: p.Status, After: propertyAbsent})
+		}
+	}
+	return d
+}
+
+func (d *reportDiff) empty() bool {
+	return len(d.NewlyFailing) == 0 && len(d.NewlyPassing) == 0 && len(d.NewlyReached) == 0 && len(d.Disappeared) == 0
+}
+
+func reportDiffCommand(c HTTPClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <base-run-id> <head-run-id>",
+		Long:  fmt.Sprintf("Compare the outcomes of the properties of two test runs, listing the properties that are newly failing, newly passing, newly reached and that disappeared in the head run. Exits with %d when there are newly failing properties.", exitTestFailures),
+		Short: "Compare the reports of two test runs",
+		Example: `
+# Check whether a change introduced new failures
+antithesis report diff 3f2a9c 7b1e04
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			base, _, err := fetchReport(c, creds, args[0])
+			if err != nil {
+				return err
+			}
+			head, _, err := fetchReport(c, creds, args[1])
+			if err != nil {
+				return err
+			}
+
+			diff := diffReports(base, head)
+			if err := printOutput(cmd, output, diff, func() error {
+				prettyPrintReportDiff(cmd, diff)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if n := len(diff.NewlyFailing); n > 0 {
+				return &exitCodeError{
+					code: exitTestFailures,
+					err:  fmt.Errorf("test run %s has %d newly failing properties compared to %s", diff.Head, n, diff.Base),
+				}
+			}
+			return nil
+		},
+	}
+
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+func prettyPrintReportDiff(cmd *cobra.Command, d *reportDiff) {
+	cmd.Println(HeaderStyle.Render(fmt.Sprintf("Diff of test run %s against %s", d.Head, d.Base)))
+	if d.empty() {
+		cmd.Println("No property changed outcome.")
+		return
+	}
+
+	sections := []struct {
+		title   string
+		marker  string
+		changes []propertyChange
+	}{
+		{ErrorStyle.Render("Newly failing:"), "✗", d.NewlyFailing},
+		{SuccessStyle.Render("Newly passing:"), "✓", d.NewlyPassing},
+		{SuccessStyle.Render("Newly reached:"), "+", d.NewlyReached},
+		{WarningStyle.Render("Disappeared:"), "-", d.Disappeared},
+	}
+	for _, s := range sections {
+		if len(s.changes) == 0 {
+			continue
+		}
+		cmd.Printf("%s\n", s.title)
+		for _, change := range s.changes {
+			cmd.Printf("  %s %s %s\n", s.marker, change.Name, SubtleStyle.Render(fmt.Sprintf("(%s → %s)", change.Before, change.After)))
+		}
+		cmd.Println()
+	}
+}
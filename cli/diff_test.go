@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffReports(t *testing.T) {
+	base, head := testReports["run-1"], testReports["run-2"]
+
+	d := diffReports(&base, &head)
+	assert.Equal(t, "run-1", d.Base)
+	assert.Equal(t, "run-2", d.Head)
+	assert.Equal(t, []propertyChange{{Name: "Payments are idempotent", Before: propertyPassed, After: propertyFailed}}, d.NewlyFailing)
+	assert.Equal(t, []propertyChange{{Name: "Balance is never negative", Before: propertyFailed, After: propertyPassed}}, d.NewlyPassing)
+	assert.Equal(t, []propertyChange{
+		{Name: "Refunds are reached", Before: propertyUnfound, After: propertyPassed},
+		{Name: "Shipments are sent", Before: propertyAbsent, After: propertyPassed},
+	}, d.NewlyReached)
+	assert.Equal(t, []propertyChange{{Name: "Orders are processed", Before: propertyPassed, After: propertyAbsent}}, d.Disappeared)
+
+	d = diffReports(&base, &base)
+	assert.True(t, d.empty())
+}
+
+func TestReportDiffCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	tcs := []struct {
+		name             string
+		args             []string
+		expectedOutput   []string
+		expectedErr      string
+		expectedExitCode int
+	}{
+		{
+			name:             "Regressions",
+			args:             []string{"diff", "run-1", "run-2"},
+			expectedOutput:   []string{"Newly failing:", "Payments are idempotent", "Newly passing:", "Newly reached:", "Disappeared:", "Orders are processed"},
+			expectedErr:      "test run run-2 has 1 newly failing properties compared to run-1",
+			expectedExitCode: exitTestFailures,
+		},
+		{
+			name:           "No changes",
+			args:           []string{"diff", "run-1", "run-1"},
+			expectedOutput: []string{"No property changed outcome."},
+		},
+		{
+			name:             "Unknown run",
+			args:             []string{"diff", "run-1", "run-3"},
+			expectedErr:      "unexpected non-200 status code: 404: report not found",
+			expectedExitCode: exitError,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := reportCommand(newFakeServerClient(t, fakeReportAPI(t)))
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.Equal(t, tc.expectedExitCode, ExitCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, s := range tc.expectedOutput {
+				assert.Contains(t, stdout.String(), s)
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		cmd := reportCommand(newFakeServerClient(t, fakeReportAPI(t)))
+		addGlobalFlags(cmd)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"diff", "run-1", "run-2", "--output=json"})
+
+		assert.Error(t, cmd.Execute())
+
+		d := reportDiff{}
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &d))
+		assert.Len(t, d.NewlyFailing, 1)
+		assert.Len(t, d.NewlyPassing, 1)
+		assert.Len(t, d.NewlyReached, 2)
+		assert.Len(t, d.Disappeared, 1)
+	})
+}
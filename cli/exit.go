@@ -0,0 +1,34 @@
+package cli
+
+import "errors"
+
+// Exit codes of the CLI, so that scripts can tell why a command failed.
+const (
+	exitError               = 1
+	exitTestFailures        = 2
+	exitInfrastructureError = 3
+	exitTimeout             = 4
+)
+
+// exitCodeError is an error that sets the exit code of the CLI.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the exit code of the CLI for an error returned by Main.
+func ExitCode(err error) int {
+	var e *exitCodeError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitError
+}
@@ -153,10 +153,13 @@ func downloadAndExtractProject(url, dir string) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("failed to download project: %s", resp.Status)
 	}
-	return untar(resp.Body, dir)
+	return untar(resp.Body, dir, true)
 }
 
-func untar(r io.Reader, dst string) error {
+// untar extracts a gzipped tarball into dst. When stripTopLevel is set, the
+// top-level directory of the tarball, e.g. the one created by GitHub, is not
+// created.
+func untar(r io.Reader, dst string, stripTopLevel bool) error {
 	gzr, err := gzip.NewReader(r)
 	if err != nil {
 		return err
@@ -178,15 +181,24 @@ func untar(r io.Reader, dst string) error {
 			continue
 		}
 
-		// We don't want the top-level directory created by GitHub.
-		if topLevelDir == "" {
-			parts := strings.Split(header.Name, "/")
-			if len(parts) > 1 {
-				topLevelDir = parts[0]
+		name := header.Name
+		if stripTopLevel {
+			if topLevelDir == "" {
+				parts := strings.Split(header.Name, "/")
+				if len(parts) > 1 {
+					topLevelDir = parts[0]
+				}
+			}
+			name = strings.TrimPrefix(header.Name, topLevelDir+"/")
+		}
+		// Don't let the tarball write outside of dst.
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			if name == "" || name == "." || name == "./" {
+				continue
 			}
+			return fmt.Errorf("invalid path %q in archive", header.Name)
 		}
-		headNameWithoutTop := strings.TrimPrefix(header.Name, topLevelDir+"/")
-		target := filepath.Join(dst, headNameWithoutTop)
+		target := filepath.Join(dst, name)
 
 		switch header.Typeflag {
 		case tar.TypeDir:
@@ -199,12 +211,15 @@ func untar(r io.Reader, dst string) error {
 			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
 				return err
 			}
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
 				return err
 			}
-			defer f.Close()
 			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
 				return err
 			}
 		}
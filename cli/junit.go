@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JUnit XML, as ingested by CI dashboards such as Jenkins, GitLab and
+// Buildkite. Each property of a report is a testcase.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes the properties of a report as JUnit XML. Unfound
+// properties are skipped, or failed if unfoundAsFailure is set.
+func writeJUnit(w io.Writer, report *testReport, unfoundAsFailure bool) error {
+	suite := junitTestSuite{Name: report.Name}
+	for _, p := range report.Properties {
+		tc := junitTestCase{Name: p.Name, ClassName: "antithesis"}
+		if p.Type != "" {
+			tc.ClassName += "." + p.Type
+		}
+		switch {
+		case p.Status == propertyFailed:
+			message := p.Message
+			if message == "" {
+				message = "property failed"
+			}
+			tc.Failure = &junitFailure{
+				Message: message,
+				Type:    "failed",
+				Text:    strings.Join(p.Examples, "\n\n"),
+			}
+		// Never reaching an AlwaysOrUnreachable assertion means it holds.
+		case p.Status == propertyUnfound && p.Type == "AlwaysOrUnreachable":
+		case p.Status == propertyUnfound:
+			message := "property was never reached"
+			if p.Type != "" {
+				message = fmt.Sprintf("%s assertion was never reached", p.Type)
+			}
+			if unfoundAsFailure {
+				tc.Failure = &junitFailure{Message: message, Type: "unfound"}
+			} else {
+				tc.Skipped = &junitSkipped{Message: message}
+			}
+		}
+		suite.Tests++
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+		if tc.Skipped != nil {
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	suites := junitTestSuites{
+		Name:     fmt.Sprintf("Antithesis test run %s", report.RunID),
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
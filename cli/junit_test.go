@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	report := testReports["run-1"]
+
+	tcs := []struct {
+		name             string
+		unfoundAsFailure bool
+		expectedFailures int
+		expectedSkipped  int
+	}{
+		{
+			name:             "Unfound as skipped",
+			expectedFailures: 2,
+			expectedSkipped:  1,
+		},
+		{
+			name:             "Unfound as failure",
+			unfoundAsFailure: true,
+			expectedFailures: 3,
+			expectedSkipped:  0,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			assert.NoError(t, writeJUnit(out, &report, tc.unfoundAsFailure))
+
+			suites := junitTestSuites{}
+			assert.NoError(t, xml.Unmarshal(out.Bytes(), &suites))
+			assert.Equal(t, 5, suites.Tests)
+			assert.Equal(t, tc.expectedFailures, suites.Failures)
+			assert.Equal(t, tc.expectedSkipped, suites.Skipped)
+			if !assert.Len(t, suites.Suites, 1) || !assert.Len(t, suites.Suites[0].Cases, 5) {
+				return
+			}
+
+			cases := suites.Suites[0].Cases
+			assert.Equal(t, "antithesis.Sometimes", cases[0].ClassName)
+			assert.Nil(t, cases[0].Failure)
+			assert.Nil(t, cases[0].Skipped)
+			if assert.NotNil(t, cases[2].Failure) {
+				assert.Equal(t, "balance was -10", cases[2].Failure.Message)
+				assert.Equal(t, "[payment] balance=-10", cases[2].Failure.Text)
+			}
+			if assert.NotNil(t, cases[3].Failure) {
+				assert.Equal(t, "property failed", cases[3].Failure.Message)
+			}
+			if tc.unfoundAsFailure {
+				assert.NotNil(t, cases[4].Failure)
+			} else if assert.NotNil(t, cases[4].Skipped) {
+				assert.Equal(t, "Reachable assertion was never reached", cases[4].Skipped.Message)
+			}
+		})
+	}
+}
+
+func TestWriteJUnitUnreachable(t *testing.T) {
+	report := testReport{RunID: "run-3", Properties: []reportProperty{
+		{Name: "Refunds never exceed payments", Type: "AlwaysOrUnreachable", Status: propertyUnfound},
+		{Name: "Refunds are reached", Type: "Reachable", Status: propertyUnfound},
+	}}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, writeJUnit(out, &report, true))
+
+	suites := junitTestSuites{}
+	assert.NoError(t, xml.Unmarshal(out.Bytes(), &suites))
+	assert.Equal(t, 1, suites.Failures)
+	if assert.Len(t, suites.Suites, 1) && assert.Len(t, suites.Suites[0].Cases, 2) {
+		assert.Nil(t, suites.Suites[0].Cases[0].Failure)
+		assert.Nil(t, suites.Suites[0].Cases[0].Skipped)
+		assert.NotNil(t, suites.Suites[0].Cases[1].Failure)
+	}
+}
+
+func TestReportCommandJUnit(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	cmd := reportCommand(newFakeServerClient(t, fakeReportAPI(t)))
+	stdout := &bytes.Buffer{}
+	cmd.SetOut(stdout)
+	cmd.SetArgs([]string{"run-1", "--format=junit"})
+
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), xml.Header)
+	assert.Contains(t, stdout.String(), `<testsuites name="Antithesis test run run-1" tests="5" failures="2" skipped="1">`)
+
+	cmd = reportCommand(newFakeServerClient(t, fakeReportAPI(t)))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"run-1", "--format=xml"})
+	assert.EqualError(t, cmd.Execute(), `format "xml" is not supported, available formats are: summary, junit, sarif`)
+}
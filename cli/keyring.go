@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	keyringService = "antithesis-cli"
+
+	// keychainItemNotFound is the exit code of the macOS security tool when
+	// there is no such password.
+	keychainItemNotFound = 44
+)
+
+// keyringSecretStore keeps the passwords in the OS keyring: the Keychain on
+// macOS, the Credential Manager on Windows and the Secret Service (GNOME
+// Keyring, KWallet, ...) on Linux.
+type keyringSecretStore struct{}
+
+func keyringAvailable() bool {
+	switch runtime.GOOS {
+	case "windows":
+		return true
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	// Linux.
+	default:
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return false
+		}
+		// The Secret Service needs a session bus, which headless machines lack.
+		return exec.Command("secret-tool", "search", "service", keyringService).Run() == nil
+	}
+}
+
+func (s *keyringSecretStore) Get(tenant string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		return wincredGet(keyringService + ":" + tenant)
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", tenant, "-w")
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "tenant", tenant)
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil && len(out) == 0:
+		return "", errSecretNotFound
+	// security exits with errSecItemNotFound, and secret-tool silently fails
+	// when there is no such password.
+	case errors.As(err, &exitErr) && runtime.GOOS == "darwin" && exitErr.ExitCode() == keychainItemNotFound:
+		return "", errSecretNotFound
+	case errors.As(err, &exitErr) && runtime.GOOS != "darwin" && exitErr.ExitCode() == 1 && stderr.Len() == 0:
+		return "", errSecretNotFound
+	case err != nil:
+		return "", fmt.Errorf("failed to read password from the OS keyring: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (s *keyringSecretStore) Set(tenant, password string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		return wincredSet(keyringService+":"+tenant, tenant, password)
+	case "darwin":
+		// Commands are piped to an interactive session so that the password
+		// never shows up in the process list.
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			shellQuote(keyringService), shellQuote(tenant), shellQuote(password)))
+	default:
+		cmd = exec.Command("secret-tool", "store", "--label", "Antithesis ("+tenant+")", "service", keyringService, "tenant", tenant)
+		cmd.Stdin = strings.NewReader(password)
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to store password in the OS keyring: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (s *keyringSecretStore) Delete(tenant string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		return wincredDelete(keyringService + ":" + tenant)
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", tenant)
+	default:
+		cmd = exec.Command("secret-tool", "clear", "service", keyringService, "tenant", tenant)
+	}
+	// Deleting a missing password is not an error.
+	_ = cmd.Run()
+	return nil
+}
+
+func shellQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package cli
+
+import "errors"
+
+var errNoCredentialManager = errors.New("the Windows Credential Manager is only available on Windows")
+
+func wincredGet(target string) (string, error) {
+	return "", errNoCredentialManager
+}
+
+func wincredSet(target, username, password string) error {
+	return errNoCredentialManager
+}
+
+func wincredDelete(target string) error {
+	return errNoCredentialManager
+}
@@ -0,0 +1,96 @@
+//go:build windows
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	advapi32       = windows.NewLazySystemDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// winCredential mirrors the CREDENTIALW structure of wincred.h.
+type winCredential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func wincredGet(target string) (string, error) {
+	targetPtr, err := windows.UTF16PtrFromString(target)
+	if err != nil {
+		return "", err
+	}
+	var cred *winCredential
+	ret, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(targetPtr)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		if errors.Is(err, windows.ERROR_NOT_FOUND) {
+			return "", errSecretNotFound
+		}
+		return "", fmt.Errorf("failed to read the Windows Credential Manager: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func wincredSet(target, username, password string) error {
+	targetPtr, err := windows.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	usernamePtr, err := windows.UTF16PtrFromString(username)
+	if err != nil {
+		return err
+	}
+	cred := winCredential{
+		Type:               credTypeGeneric,
+		TargetName:         targetPtr,
+		CredentialBlobSize: uint32(len(password)),
+		Persist:            credPersistLocalMachine,
+		UserName:           usernamePtr,
+	}
+	if len(password) > 0 {
+		blob := []byte(password)
+		cred.CredentialBlob = &blob[0]
+	}
+	ret, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return fmt.Errorf("failed to write the Windows Credential Manager: %w", err)
+	}
+	return nil
+}
+
+func wincredDelete(target string) error {
+	targetPtr, err := windows.UTF16PtrFromString(target)
+	if err != nil {
+		return err
+	}
+	ret, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(targetPtr)), credTypeGeneric, 0)
+	if ret == 0 && !errors.Is(err, windows.ERROR_NOT_FOUND) {
+		return fmt.Errorf("failed to delete from the Windows Credential Manager: %w", err)
+	}
+	return nil
+}
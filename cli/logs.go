@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// logLine is a line of the container logs or of the event log of a run.
+type logLine struct {
+	Time time.Time `json:"time" yaml:"time"`
+	// Source is the container that wrote the line, or the kind of event.
+	Source  string `json:"source" yaml:"source"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// logsPage is a page of logs. The cursor points past its last line, and is
+// passed back to get the next lines.
+type logsPage struct {
+	Lines  []logLine `json:"lines"`
+	Cursor string    `json:"cursor"`
+	// More is set when more lines are available right away.
+	More bool `json:"more"`
+}
+
+func logsCommand(c HTTPClient) *cobra.Command {
+	var (
+		containers   []string
+		since        string
+		until        string
+		events       bool
+		follow       bool
+		save         string
+		pollInterval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:     "logs <run-id>",
+		Long:    "Print the container logs of a test run, or its event log with --events. With --follow, the logs of a run in progress are printed as they come until it completes. With --output=json or --output=yaml, each line is printed as a JSON object or a YAML document.",
+		Short:   "Print the logs of a test run",
+		GroupID: "development",
+		Example: `
+# Print the logs of the payment and order services
+antithesis logs 3f2a9c --container=payment --container=order
+
+# Follow the event log of a run in progress
+antithesis logs 3f2a9c --events --follow
+
+# Download the logs of a time window
+antithesis logs 3f2a9c --since=2024-10-18T08:00:00Z --until=2024-10-18T08:10:00Z --save=logs.txt
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			query := url.Values{}
+			for _, container := range containers {
+				query.Add("container", container)
+			}
+			for key, value := range map[string]string{"since": since, "until": until} {
+				if value == "" {
+					continue
+				}
+				t, err := parseDate(value)
+				if err != nil {
+					return fmt.Errorf("invalid --%s: %w", key, err)
+				}
+				query.Set(key, t.Format(time.RFC3339))
+			}
+			if follow && pollInterval <= 0 {
+				return fmt.Errorf("--poll-interval must be positive")
+			}
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			if save != "" {
+				f, err := os.Create(save)
+				if err != nil {
+					return fmt.Errorf("failed to save logs: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			// Lines are written as they come, so that they can be followed. The
+			// YAML encoder writes each document as soon as it is encoded, and
+			// needs no closing.
+			var enc *yaml.Encoder
+			if output == yamlOutput {
+				enc = yaml.NewEncoder(w)
+				enc.SetIndent(2)
+			}
+			write := func(l logLine) error {
+				switch output {
+				case jsonOutput:
+					return json.NewEncoder(w).Encode(l)
+				case yamlOutput:
+					return enc.Encode(l)
+				default:
+					_, err := fmt.Fprintf(w, "%s [%s] %s\n", l.Time.Format(time.RFC3339Nano), l.Source, l.Message)
+					return err
+				}
+			}
+
+			path := "runs/" + url.PathEscape(args[0]) + "/logs"
+			if events {
+				path = "runs/" + url.PathEscape(args[0]) + "/events"
+			}
+			if err := streamLogs(cmd.Context(), c, creds, args[0], path, query, follow, pollInterval, write); err != nil {
+				return err
+			}
+			if save != "" {
+				cmd.PrintErrln(SubtleStyle.Render(fmt.Sprintf("Logs saved to %s", save)))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&containers, "container", "c", make([]string, 0), "only print the logs of this container or service (can specify multiple)")
+	cmd.Flags().StringVar(&since, "since", "", "only print the lines written after this time (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&until, "until", "", "only print the lines written before this time (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().BoolVarP(&events, "events", "e", false, "print the event log instead of the container logs")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing new lines until the run completes")
+	cmd.Flags().StringVarP(&save, "save", "s", "", "save the logs to this path instead of printing them")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 5*time.Second, "time between two checks for new lines with --follow")
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+// streamLogs writes the lines of the logs at path page by page. When
+// following, it waits for new lines until the run is done.
+func streamLogs(ctx context.Context, c HTTPClient, creds credentials, id, path string, query url.Values, follow bool, pollInterval time.Duration, write func(logLine) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cursor := ""
+	done := false
+	for {
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		page := &logsPage{}
+		if err := getJSON(c, creds, path, query, page); err != nil {
+			return err
+		}
+		for _, l := range page.Lines {
+			if err := write(l); err != nil {
+				return fmt.Errorf("failed to write logs: %w", err)
+			}
+		}
+		if page.Cursor != "" {
+			cursor = page.Cursor
+		}
+		if page.More {
+			// Without a cursor, the same page would be requested forever.
+			if page.Cursor == "" {
+				return fmt.Errorf("failed to read the logs of test run %s: more lines are available but no cursor was returned to get them", id)
+			}
+			continue
+		}
+		// The last lines are read once the run is done, as it may have
+		// written more while its status was fetched.
+		if !follow || done {
+			return nil
+		}
+		status, err := fetchRunStatus(c, creds, id)
+		if err != nil {
+			return err
+		}
+		if status.done() {
+			done = true
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
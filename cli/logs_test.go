@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeLogsAPI serves the logs of a run two lines at a time. While following,
+// each status check of the run reveals two more lines, and the run completes
+// once all of them are visible.
+type fakeLogsAPI struct {
+	mu      sync.Mutex
+	lines   []logLine
+	visible int
+	queries []string
+}
+
+func (f *fakeLogsAPI) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	serveLines := func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.queries = append(f.queries, r.URL.RawQuery)
+
+		var lines []logLine
+		containers := r.URL.Query()["container"]
+		for _, l := range f.lines[:f.visible] {
+			if len(containers) == 0 || slices.Contains(containers, l.Source) {
+				lines = append(lines, l)
+			}
+		}
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		end := min(start+2, len(lines))
+		assert.NoError(t, json.NewEncoder(w).Encode(logsPage{
+			Lines:  lines[start:end],
+			Cursor: strconv.Itoa(end),
+			More:   end < len(lines),
+		}))
+	}
+	mux.HandleFunc("GET /api/v1/runs/run-1/logs", serveLines)
+	mux.HandleFunc("GET /api/v1/runs/run-1/events", serveLines)
+	mux.HandleFunc("GET /api/v1/runs/run-1", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		state := runComplete
+		if f.visible < len(f.lines) {
+			f.visible = min(f.visible+2, len(f.lines))
+			state = runRunning
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(runStatus{ID: "run-1", State: state}))
+	})
+	return mux
+}
+
+func TestLogsCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	start := time.Date(2024, 10, 18, 8, 0, 0, 0, time.UTC)
+	var lines []logLine
+	for i, source := range []string{"order", "payment", "order", "payment", "order"} {
+		lines = append(lines, logLine{Time: start.Add(time.Duration(i) * time.Second), Source: source, Message: "line " + strconv.Itoa(i)})
+	}
+	dir := t.TempDir()
+
+	tcs := []struct {
+		name           string
+		args           []string
+		visible        int
+		expectedLines  []string
+		expectedQuery  string
+		expectedFile   string
+		expectedErr    string
+		expectedOutput string
+	}{
+		{
+			name:    "All lines",
+			args:    []string{"run-1"},
+			visible: len(lines),
+			expectedLines: []string{
+				"2024-10-18T08:00:00Z [order] line 0",
+				"2024-10-18T08:00:01Z [payment] line 1",
+				"2024-10-18T08:00:02Z [order] line 2",
+				"2024-10-18T08:00:03Z [payment] line 3",
+				"2024-10-18T08:00:04Z [order] line 4",
+			},
+		},
+		{
+			name:    "Filters",
+			args:    []string{"run-1", "--container=payment", "--since=2024-10-18T08:00:00Z", "--until=2024-10-18T09:00:00Z"},
+			visible: len(lines),
+			expectedLines: []string{
+				"2024-10-18T08:00:01Z [payment] line 1",
+				"2024-10-18T08:00:03Z [payment] line 3",
+			},
+			expectedQuery: "container=payment&since=2024-10-18T08%3A00%3A00Z&until=2024-10-18T09%3A00%3A00Z",
+		},
+		{
+			name:    "Follow",
+			args:    []string{"run-1", "--events", "--follow", "--poll-interval=1ms"},
+			visible: 1,
+			expectedLines: []string{
+				"2024-10-18T08:00:00Z [order] line 0",
+				"2024-10-18T08:00:01Z [payment] line 1",
+				"2024-10-18T08:00:02Z [order] line 2",
+				"2024-10-18T08:00:03Z [payment] line 3",
+				"2024-10-18T08:00:04Z [order] line 4",
+			},
+		},
+		{
+			name:          "Save",
+			args:          []string{"run-1", "--container=order", "--save=" + filepath.Join(dir, "logs.txt")},
+			visible:       len(lines),
+			expectedFile:  filepath.Join(dir, "logs.txt"),
+			expectedLines: []string{"2024-10-18T08:00:00Z [order] line 0", "2024-10-18T08:00:02Z [order] line 2", "2024-10-18T08:00:04Z [order] line 4"},
+		},
+		{
+			name:        "Invalid time",
+			args:        []string{"run-1", "--since=now"},
+			expectedErr: `invalid --since: "now" is not a YYYY-MM-DD date or an RFC 3339 timestamp`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &fakeLogsAPI{lines: lines, visible: tc.visible}
+			cmd := logsCommand(newFakeServerClient(t, api.handler(t)))
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			output := stdout.String()
+			if tc.expectedFile != "" {
+				assert.Empty(t, output)
+				data, err := os.ReadFile(tc.expectedFile)
+				assert.NoError(t, err)
+				output = string(data)
+			}
+			assert.Equal(t, strings.Join(tc.expectedLines, "\n")+"\n", output)
+			if tc.expectedQuery != "" {
+				assert.Equal(t, tc.expectedQuery, api.queries[0])
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		api := &fakeLogsAPI{lines: lines, visible: len(lines)}
+		cmd := logsCommand(newFakeServerClient(t, api.handler(t)))
+		addGlobalFlags(cmd)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetArgs([]string{"run-1", "--output=json"})
+
+		assert.NoError(t, cmd.Execute())
+
+		dec := json.NewDecoder(stdout)
+		var decoded []logLine
+		for dec.More() {
+			l := logLine{}
+			assert.NoError(t, dec.Decode(&l))
+			decoded = append(decoded, l)
+		}
+		assert.Equal(t, lines, decoded)
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		api := &fakeLogsAPI{lines: lines, visible: len(lines)}
+		cmd := logsCommand(newFakeServerClient(t, api.handler(t)))
+		addGlobalFlags(cmd)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetArgs([]string{"run-1", "--output=yaml"})
+
+		assert.NoError(t, cmd.Execute())
+
+		dec := yaml.NewDecoder(stdout)
+		var decoded []logLine
+		for {
+			l := logLine{}
+			if err := dec.Decode(&l); errors.Is(err, io.EOF) {
+				break
+			} else {
+				assert.NoError(t, err)
+			}
+			decoded = append(decoded, l)
+		}
+		assert.Equal(t, lines, decoded)
+	})
+
+	t.Run("More lines without a cursor", func(t *testing.T) {
+		requests := 0
+		cmd := logsCommand(newFakeServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			assert.NoError(t, json.NewEncoder(w).Encode(logsPage{Lines: lines[:1], More: true}))
+		})))
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"run-1"})
+
+		assert.EqualError(t, cmd.Execute(), "failed to read the logs of test run run-1: more lines are available but no cursor was returned to get them")
+		assert.Equal(t, 1, requests)
+	})
+}
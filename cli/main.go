@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -23,7 +25,6 @@ func AntithesisCommand() *cobra.Command {
 The entrypoint of the antithesis ecosystem. Build the impossible.`),
 		Short: "Antithesis CLI",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Environment variables, config to with updating and secrets. For GitHub Action too.
 			// Eagerly inform customers of when a new update is available.
 			if cmd.Name() == "update" {
 				return nil
@@ -41,6 +42,8 @@ The entrypoint of the antithesis ecosystem. Build the impossible.`),
 		},
 	}
 
+	addGlobalFlags(cmd)
+
 	cmd.AddGroup(&cobra.Group{
 		ID:    "management",
 		Title: "Management Commands:",
@@ -50,17 +53,31 @@ The entrypoint of the antithesis ecosystem. Build the impossible.`),
 		Title: "Development Commands:",
 	})
 
-	cmd.AddCommand(authCommand())
+	client := &http.Client{}
+	runner := execRunner{}
+
+	cmd.AddCommand(authCommand(client))
 	cmd.AddCommand(configCommand())
 	cmd.AddCommand(updateCommand())
 	cmd.AddCommand(versionCommand())
-	cmd.AddCommand(debugCommand())
+	cmd.AddCommand(buildCommand(runner))
+	cmd.AddCommand(debugCommand(client))
 	cmd.AddCommand(initCommand())
-	cmd.AddCommand(runCommand(&http.Client{}))
+	cmd.AddCommand(pushCommand(runner))
+	cmd.AddCommand(runCommand(client, runner))
+	cmd.AddCommand(runsCommand(client))
+	cmd.AddCommand(reportCommand(client))
+	cmd.AddCommand(logsCommand(client))
 
 	return cmd
 }
 
+// addGlobalFlags adds the flags shared by every command.
+func addGlobalFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("profile", "", "named tenant profile to use (defaults to $ANTITHESIS_PROFILE)")
+	cmd.PersistentFlags().StringP("output", "o", textOutput, fmt.Sprintf("output format (%s)", strings.Join(availableOutputs, ", ")))
+}
+
 func Main() error {
 	return AntithesisCommand().ExecuteContext(context.Background())
 }
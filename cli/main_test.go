@@ -7,8 +7,16 @@ import (
 )
 
 var expectedCommands = map[string]string{
+	"auth":                  "management",
+	"build [path]":          "development",
+	"config":                "management",
+	"debug <run-id>":        "development",
 	"init <project> [path]": "development",
+	"logs <run-id>":         "development",
+	"push [path]":           "development",
+	"report <run-id>":       "development",
 	"run [flags]":           "development",
+	"runs":                  "development",
 	"update":                "management",
 	"version":               "management",
 }
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// runManifest is the declarative form of 'antithesis run', meant to be checked
+// into the repository of the system under test. Its JSON Schema lives in
+// schemas/run.schema.json and must be kept in sync.
+type runManifest struct {
+	Schema      string            `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Name        string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Notebook    string            `json:"notebook,omitempty" yaml:"notebook,omitempty"`
+	Config      string            `json:"config,omitempty" yaml:"config,omitempty"`
+	Images      []string          `json:"images,omitempty" yaml:"images,omitempty"`
+	Duration    int32             `json:"duration,omitempty" yaml:"duration,omitempty"`
+	Recipients  []string          `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+	Params      map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// readRunManifest reads a YAML or JSON manifest, rejecting unknown fields.
+func readRunManifest(path string) (*runManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m := &runManifest{}
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(m)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// apply fills the flags of cmd that were not set on the command line with the
+// values of the manifest, so that flags always take precedence.
+func (m *runManifest) apply(cmd *cobra.Command) error {
+	values := map[string][]string{
+		"name":        {m.Name},
+		"description": {m.Description},
+		"notebook":    {m.Notebook},
+		"config":      {m.Config},
+		"image":       m.Images,
+		"email":       m.Recipients,
+	}
+	if m.Duration != 0 {
+		values["duration"] = []string{strconv.Itoa(int(m.Duration))}
+	}
+	for flag, vs := range values {
+		if cmd.Flags().Changed(flag) {
+			continue
+		}
+		for _, v := range vs {
+			if v == "" {
+				continue
+			}
+			if err := cmd.Flags().Set(flag, v); err != nil {
+				return fmt.Errorf("invalid manifest value for %s: %w", flag, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+)
+
+func TestRunManifestSchema(t *testing.T) {
+	data, err := os.ReadFile("../schemas/run.schema.json")
+	assert.NoError(t, err)
+	schema := struct {
+		Properties map[string]any `json:"properties"`
+	}{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+
+	var fields []string
+	manifest := reflect.TypeOf(runManifest{})
+	for i := 0; i < manifest.NumField(); i++ {
+		tag := manifest.Field(i).Tag.Get("json")
+		fields = append(fields, strings.Split(tag, ",")[0])
+	}
+	assert.ElementsMatch(t, fields, maps.Keys(schema.Properties), "the JSON Schema must describe every field of the manifest")
+}
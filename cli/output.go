@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+	yamlOutput = "yaml"
+)
+
+var (
+	availableOutputs = []string{textOutput, jsonOutput, yamlOutput}
+)
+
+// outputFormat returns the output format selected with the global --output
+// flag, the ANTITHESIS_OUTPUT environment variable or the default.output
+// config key.
+func outputFormat(r *configResolver) (string, error) {
+	output := r.Value("default.output")
+	if output == "" {
+		return textOutput, nil
+	}
+	if err := validateOutput(output); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+func validateOutput(output string) error {
+	if !slices.Contains(availableOutputs, output) {
+		return fmt.Errorf("output %q is not supported, available outputs are: %s", output, strings.Join(availableOutputs, ", "))
+	}
+	return nil
+}
+
+// printOutput prints v as JSON or YAML for tooling, or calls text to print it
+// for humans.
+func printOutput(cmd *cobra.Command, output string, v any, text func() error) error {
+	switch output {
+	case jsonOutput:
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v)
+	case yamlOutput:
+		enc := yaml.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		return enc.Close()
+	default:
+		return text()
+	}
+}
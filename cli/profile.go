@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	profilesFileName = "profiles.json"
+)
+
+// profile names a tenant and the username to authenticate with, so that users
+// working with several tenants can switch between them with --profile.
+type profile struct {
+	Tenant   string `json:"tenant"`
+	Username string `json:"username,omitempty"`
+}
+
+type profilesFile struct {
+	Profiles map[string]profile `json:"profiles"`
+}
+
+func profilesPath() (string, error) {
+	cfg, err := getUserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(cfg, profilesFileName), nil
+}
+
+// loadProfiles reads the profiles file. A missing file means there are no
+// profiles yet.
+func loadProfiles() (*profilesFile, error) {
+	f := &profilesFile{Profiles: make(map[string]profile)}
+	path, err := profilesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+	}
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]profile)
+	}
+	return f, nil
+}
+
+func (f *profilesFile) save() error {
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// lookup returns the profile called name, failing if it doesn't exist.
+func (f *profilesFile) lookup(name string) (profile, error) {
+	p, ok := f.Profiles[name]
+	if !ok {
+		return p, fmt.Errorf("profile %s does not exist, create it with 'antithesis auth login --profile=%s'", ValueStyle.Render(fmt.Sprintf("'%s'", name)), name)
+	}
+	return p, nil
+}
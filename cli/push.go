@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	lockfileName = "antithesis.lock"
+)
+
+// pushedImage is an image pushed to a registry. Digest is the reference of
+// the image pinned to its digest, e.g. registry/order@sha256:...
+type pushedImage struct {
+	Service string `json:"service" yaml:"service"`
+	Image   string `json:"image" yaml:"image"`
+	Digest  string `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Config  bool   `json:"config,omitempty" yaml:"config,omitempty"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type pushResult struct {
+	Tag      string        `json:"tag" yaml:"tag"`
+	Images   []pushedImage `json:"images" yaml:"images"`
+	Lockfile string        `json:"lockfile,omitempty" yaml:"lockfile,omitempty"`
+}
+
+// lockfile pins the images of a project to the digests they were pushed
+// with, so that later runs test exactly these images.
+type lockfile struct {
+	Tag    string `yaml:"tag"`
+	Config string `yaml:"config"`
+	// Services maps the name of each service to its pinned image.
+	Services map[string]string `yaml:"services"`
+}
+
+func pushCommand(runner CommandRunner) *cobra.Command {
+	var tag string
+
+	cmd := &cobra.Command{
+		Use:     "push [path]",
+		Long:    fmt.Sprintf("Push the images of a project built with 'antithesis build' to the private registry of the tenant, logging into it with your Antithesis credentials, or to the registry set with --registry, which you must be logged into. Once every image is pushed, their digests are written to %s at the root of the project, which 'antithesis run' uses to test exactly these images.", lockfileName),
+		Short:   "Push the images of a project",
+		GroupID: "development",
+		Example: `
+# Push the images built from the current commit
+antithesis push
+
+# Push the images of a project built with a given tag
+antithesis push ./quickstart --tag=v1
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			engine, err := containerEngine(r)
+			if err != nil {
+				return err
+			}
+			registry, err := loginRegistry(cmd, runner, r, engine)
+			if err != nil {
+				return err
+			}
+			images, err := resolveProjectImages(runner, dir, registry, tag)
+			if err != nil {
+				return err
+			}
+
+			result, pushErr := pushProject(cmd, runner, engine, dir, images)
+			if err := printOutput(cmd, output, result, func() error {
+				prettyPrintPushResult(cmd, result)
+				return nil
+			}); err != nil {
+				return err
+			}
+			return pushErr
+		},
+	}
+
+	cmd.Flags().StringVar(&tag, "tag", "", "tag of the images (defaults to the short git commit of the project)")
+	cmd.Flags().String("registry", "", "registry to push the images to (defaults to the registry of the tenant)")
+	cmd.Flags().String("engine", "", fmt.Sprintf("container engine used to push the images (%s, defaults to the one installed)", strings.Join(availableEngines, ", ")))
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+// loginRegistry returns the registry to push to. The private registry of the
+// tenant is logged into with the credentials of the CLI, while a registry set
+// with build.registry is expected to be logged into already.
+func loginRegistry(cmd *cobra.Command, runner CommandRunner, r *configResolver, engine string) (string, error) {
+	registry, creds, err := projectRegistry(r)
+	if err != nil || creds == nil {
+		return registry, err
+	}
+	host, _, _ := strings.Cut(registry, "/")
+
+	cmd.PrintErrln(SubtleStyle.Render(fmt.Sprintf("Logging into %s...", host)))
+	c := exec.Command(engine, "login", "--username", creds.Username, "--password-stdin", host)
+	c.Stdin = strings.NewReader(creds.Password)
+	c.Stdout = cmd.ErrOrStderr()
+	c.Stderr = cmd.ErrOrStderr()
+	if err := runner.Run(c); err != nil {
+		return "", fmt.Errorf("failed to log into the registry of tenant '%s': %w", creds.Tenant, err)
+	}
+	return registry, nil
+}
+
+// pushProject pushes every image of a project, even when some fail, and
+// writes the lockfile of the project once all of them are pushed.
+func pushProject(cmd *cobra.Command, runner CommandRunner, engine, dir string, images *buildResult) (*pushResult, error) {
+	result := &pushResult{Tag: images.Tag}
+	lock := lockfile{Tag: images.Tag, Services: make(map[string]string)}
+	failed := 0
+	for _, image := range images.Images {
+		pushed := pushedImage{Service: image.Service, Image: image.Image, Config: image.Config}
+		digest, err := pushImage(cmd, runner, engine, image.Image)
+		if err != nil {
+			pushed.Error = err.Error()
+			failed++
+		}
+		pushed.Digest = digest
+		result.Images = append(result.Images, pushed)
+
+		if image.Config {
+			lock.Config = digest
+		} else {
+			lock.Services[image.Service] = digest
+		}
+	}
+	if failed > 0 {
+		return result, fmt.Errorf("failed to push %d of %d images", failed, len(images.Images))
+	}
+
+	path := filepath.Join(dir, lockfileName)
+	if err := writeLockfile(path, &lock); err != nil {
+		return result, err
+	}
+	result.Lockfile = path
+	return result, nil
+}
+
+// pushImage pushes an image and returns its reference pinned to the digest
+// it was pushed with.
+func pushImage(cmd *cobra.Command, runner CommandRunner, engine, image string) (string, error) {
+	cmd.PrintErrln(SubtleStyle.Render(fmt.Sprintf("Pushing %s...", image)))
+	c := exec.Command(engine, "push", image)
+	c.Stdout = cmd.ErrOrStderr()
+	c.Stderr = cmd.ErrOrStderr()
+	if err := runner.Run(c); err != nil {
+		return "", fmt.Errorf("failed to push %s: %w", image, err)
+	}
+
+	stdout := &bytes.Buffer{}
+	c = exec.Command(engine, "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	c.Stdout = stdout
+	c.Stderr = cmd.ErrOrStderr()
+	if err := runner.Run(c); err != nil {
+		return "", fmt.Errorf("failed to get the digest of %s: %w", image, err)
+	}
+	var digests []string
+	if err := json.Unmarshal(stdout.Bytes(), &digests); err != nil {
+		return "", fmt.Errorf("failed to get the digest of %s: %w", image, err)
+	}
+	// An image has a digest per repository it was pushed to.
+	repository := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository = image[:i]
+	}
+	for _, digest := range digests {
+		name, sum, ok := strings.Cut(digest, "@")
+		if ok && normalizeRepository(name) == normalizeRepository(repository) {
+			return repository + "@" + sum, nil
+		}
+	}
+	return "", fmt.Errorf("failed to get the digest of %s: no digest for %s", image, repository)
+}
+
+// normalizeRepository returns the full name of a repository. Docker reports
+// the repositories of Docker Hub by their short names, e.g. acme/order for
+// docker.io/acme/order and ubuntu for docker.io/library/ubuntu.
+func normalizeRepository(name string) string {
+	domain, path, ok := strings.Cut(name, "/")
+	if !ok || (!strings.ContainsAny(domain, ".:") && domain != "localhost") {
+		domain, path = "docker.io", name
+	}
+	if domain == "index.docker.io" {
+		domain = "docker.io"
+	}
+	if domain == "docker.io" && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return domain + "/" + path
+}
+
+// readLockfile reads the lockfile of the project in dir. It returns nil when
+// the project has none.
+func readLockfile(dir string) (*lockfile, error) {
+	path := filepath.Join(dir, lockfileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	lock := &lockfile{}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if lock.Config == "" {
+		return nil, fmt.Errorf("failed to parse lockfile %s: no config image, push the project again", path)
+	}
+	return lock, nil
+}
+
+func writeLockfile(path string, lock *lockfile) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	data = append([]byte("# Generated by 'antithesis push'. Do not edit.\n"), data...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+func prettyPrintPushResult(cmd *cobra.Command, result *pushResult) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tDIGEST")
+	for _, image := range result.Images {
+		digest := image.Digest
+		if image.Error != "" {
+			digest = ErrorStyle.Render(image.Error)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", image.Service, digest)
+	}
+	w.Flush()
+	if result.Lockfile != "" {
+		cmd.Println()
+		cmd.Println(SuccessStyle.Render(fmt.Sprintf("Digests written to %s", result.Lockfile)))
+	}
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_BUILD_ENGINE", "")
+	t.Setenv("ANTITHESIS_REGISTRY", "")
+	t.Setenv("ANTITHESIS_TENANT", "acme")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	registry := "us-central1-docker.pkg.dev/molten-verve-216720/acme-repository"
+
+	tcs := []struct {
+		name             string
+		args             []string
+		fail             string
+		expectedCommands []string
+		expectedStdins   []string
+		expectedDigests  []string
+		expectedLockfile string
+		expectedErr      string
+	}{
+		{
+			name: "Tenant registry",
+			args: []string{"--engine=docker", "--tag=v1"},
+			expectedCommands: []string{
+				"docker login --username user --password-stdin us-central1-docker.pkg.dev",
+				"docker push " + registry + "/config:v1",
+				"docker image inspect --format {{json .RepoDigests}} " + registry + "/config:v1",
+				"docker push " + registry + "/order:v1",
+				"docker image inspect --format {{json .RepoDigests}} " + registry + "/order:v1",
+			},
+			expectedStdins:  []string{"pass", "", "", "", ""},
+			expectedDigests: []string{registry + "/config@sha256:v1", registry + "/order@sha256:v1"},
+			expectedLockfile: `# Generated by 'antithesis push'. Do not edit.
+tag: v1
+config: ` + registry + `/config@sha256:v1
+services:
+    order: ` + registry + `/order@sha256:v1
+`,
+		},
+		{
+			name: "Registry",
+			args: []string{"--engine=podman", "--registry=docker.io/acme"},
+			expectedCommands: []string{
+				"git rev-parse --short HEAD",
+				"podman push docker.io/acme/config:abc1234",
+				"podman image inspect --format {{json .RepoDigests}} docker.io/acme/config:abc1234",
+				"podman push docker.io/acme/order:abc1234",
+				"podman image inspect --format {{json .RepoDigests}} docker.io/acme/order:abc1234",
+			},
+			expectedDigests: []string{"docker.io/acme/config@sha256:abc1234", "docker.io/acme/order@sha256:abc1234"},
+		},
+		{
+			name:        "Login failure",
+			args:        []string{"--engine=docker", "--tag=v1"},
+			fail:        "login",
+			expectedErr: "failed to log into the registry of tenant 'acme': exit status 1",
+		},
+		{
+			name:            "Push failure",
+			args:            []string{"--engine=docker", "--tag=v1"},
+			fail:            "push " + registry + "/config",
+			expectedDigests: []string{"", registry + "/order@sha256:v1"},
+			expectedErr:     "failed to push 1 of 2 images",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeProject(t, "config/docker-compose.yaml", "order/Dockerfile")
+			runner := &fakeRunner{fail: tc.fail}
+			cmd := pushCommand(runner)
+			addGlobalFlags(cmd)
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{dir, "--output=json"}, tc.args...))
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.expectedCommands != nil {
+				assert.Equal(t, tc.expectedCommands, runner.commands)
+			}
+			if tc.expectedStdins != nil {
+				assert.Equal(t, tc.expectedStdins, runner.stdins)
+			}
+			if tc.expectedDigests == nil {
+				return
+			}
+
+			result := pushResult{}
+			assert.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+			var digests []string
+			for _, image := range result.Images {
+				digests = append(digests, image.Digest)
+			}
+			assert.Equal(t, tc.expectedDigests, digests)
+
+			lock, err := os.ReadFile(filepath.Join(dir, lockfileName))
+			if tc.expectedErr != "" {
+				assert.True(t, os.IsNotExist(err), "no lockfile must be written when an image failed to push")
+				return
+			}
+			assert.NoError(t, err)
+			if tc.expectedLockfile != "" {
+				assert.Equal(t, tc.expectedLockfile, string(lock))
+			}
+		})
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		dir := writeProject(t, "config/docker-compose.yaml", "order/Dockerfile")
+		cmd := pushCommand(&fakeRunner{})
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{dir, "--engine=docker", "--tag=v1"})
+
+		assert.NoError(t, cmd.Execute())
+		lines := strings.Split(stdout.String(), "\n")
+		assert.Equal(t, "SERVICE  DIGEST", strings.TrimSpace(lines[0]))
+		assert.Equal(t, "order    "+registry+"/order@sha256:v1", lines[2])
+		assert.Contains(t, stdout.String(), "Digests written to "+filepath.Join(dir, lockfileName))
+	})
+}
+
+func TestNormalizeRepository(t *testing.T) {
+	tcs := []struct {
+		name     string
+		expected string
+	}{
+		{name: "ubuntu", expected: "docker.io/library/ubuntu"},
+		{name: "acme/order", expected: "docker.io/acme/order"},
+		{name: "docker.io/acme/order", expected: "docker.io/acme/order"},
+		{name: "index.docker.io/library/ubuntu", expected: "docker.io/library/ubuntu"},
+		{name: "localhost/order", expected: "localhost/order"},
+		{name: "localhost:5000/order", expected: "localhost:5000/order"},
+		{name: "us-central1-docker.pkg.dev/project/acme-repository/order", expected: "us-central1-docker.pkg.dev/project/acme-repository/order"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, normalizeRepository(tc.name))
+		})
+	}
+}
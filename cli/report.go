@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	propertyPassed  = "passed"
+	propertyFailed  = "failed"
+	propertyUnfound = "unfound"
+)
+
+const (
+	summaryFormat = "summary"
+	junitFormat   = "junit"
+	sarifFormat   = "sarif"
+)
+
+var (
+	reportFormats = []string{summaryFormat, junitFormat, sarifFormat}
+)
+
+// testReport is the triage report of a test run, listing the properties it
+// checked.
+type testReport struct {
+	RunID      string           `json:"run_id" yaml:"run_id"`
+	Name       string           `json:"name" yaml:"name"`
+	ReportURL  string           `json:"report_url,omitempty" yaml:"report_url,omitempty"`
+	Properties []reportProperty `json:"properties" yaml:"properties"`
+}
+
+// reportProperty is a property checked by a test run, e.g. an assertion of
+// the Antithesis SDK.
+type reportProperty struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Type is the kind of assertion, e.g. Always, Sometimes or Reachable.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Status is either passed, failed or unfound, when the property was never
+	// reached.
+	Status string `json:"status" yaml:"status"`
+	// New is set for failures that did not happen in the previous runs.
+	New     bool   `json:"new,omitempty" yaml:"new,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// Examples are excerpts of the logs of example failures.
+	Examples []string `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// Location is where the assertion is in the source code, when the SDK
+	// provides it.
+	Location *propertyLocation `json:"location,omitempty" yaml:"location,omitempty"`
+}
+
+type propertyLocation struct {
+	File string `json:"file" yaml:"file"`
+	Line int    `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+// reportSummary counts the properties of a report by status.
+type reportSummary struct {
+	Passed            int `json:"passed" yaml:"passed"`
+	Failed            int `json:"failed" yaml:"failed"`
+	Unfound           int `json:"unfound" yaml:"unfound"`
+	NewFailures       int `json:"new_failures" yaml:"new_failures"`
+	RecurringFailures int `json:"recurring_failures" yaml:"recurring_failures"`
+}
+
+func (r *testReport) summary() reportSummary {
+	s := reportSummary{}
+	for _, p := range r.Properties {
+		switch p.Status {
+		case propertyPassed:
+			s.Passed++
+		case propertyFailed:
+			s.Failed++
+			if p.New {
+				s.NewFailures++
+			} else {
+				s.RecurringFailures++
+			}
+		case propertyUnfound:
+			s.Unfound++
+		}
+	}
+	return s
+}
+
+// filter returns the properties with the given status.
+func (r *testReport) filter(status string) []reportProperty {
+	var properties []reportProperty
+	for _, p := range r.Properties {
+		if p.Status == status {
+			properties = append(properties, p)
+		}
+	}
+	return properties
+}
+
+func reportCommand(c HTTPClient) *cobra.Command {
+	var (
+		save             string
+		format           string
+		unfoundAsFailure bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "report <run-id>",
+		Long:    "Print a summary of the triage report of a test run: its passed, failed and unfound properties, and which failures are new. The full report can be saved as HTML or JSON with --save, and exported for CI dashboards as JUnit XML with --format=junit or for code scanning as SARIF with --format=sarif.",
+		Short:   "Print the triage report of a test run",
+		GroupID: "development",
+		Example: `
+# Print the summary of a test run's report
+antithesis report 3f2a9c
+
+# Save the full report as HTML
+antithesis report 3f2a9c --save=report.html
+
+# Export the properties as JUnit XML, failing the unfound ones
+antithesis report 3f2a9c --format=junit --unfound-as-failure > antithesis.xml
+
+# Export the failed properties as SARIF, for GitHub code scanning
+antithesis report 3f2a9c --format=sarif > antithesis.sarif
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			if !slices.Contains(reportFormats, format) {
+				return fmt.Errorf("format %q is not supported, available formats are: %s", format, strings.Join(reportFormats, ", "))
+			}
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			report, data, err := fetchReport(c, creds, args[0])
+			if err != nil {
+				return err
+			}
+
+			if save != "" {
+				// The JSON report is saved as sent by the server, so that it
+				// keeps the fields the CLI doesn't know about.
+				if strings.EqualFold(filepath.Ext(save), ".html") {
+					data, err = get(c, creds, reportPath(args[0]), nil, "text/html")
+					if err != nil {
+						return err
+					}
+				}
+				if err := os.WriteFile(save, data, 0644); err != nil {
+					return fmt.Errorf("failed to save report: %w", err)
+				}
+				cmd.PrintErrln(SubtleStyle.Render(fmt.Sprintf("Report saved to %s", save)))
+			}
+
+			switch format {
+			case junitFormat:
+				return writeJUnit(cmd.OutOrStdout(), report, unfoundAsFailure)
+			case sarifFormat:
+				return writeSARIF(cmd.OutOrStdout(), report)
+			}
+
+			result := struct {
+				*testReport `yaml:",inline"`
+				Summary     reportSummary `json:"summary" yaml:"summary"`
+			}{report, report.summary()}
+			return printOutput(cmd, output, result, func() error {
+				prettyPrintReport(cmd, report)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&save, "save", "s", "", "save the full report to this path, as HTML if it ends with .html and as JSON otherwise")
+	cmd.Flags().StringVar(&format, "format", summaryFormat, fmt.Sprintf("format of the report (%s), the summary being printed with --output", strings.Join(reportFormats, ", ")))
+	cmd.Flags().BoolVar(&unfoundAsFailure, "unfound-as-failure", false, "report the unfound properties, e.g. Reachable and Sometimes assertions, as failures instead of skipped with --format=junit")
+	addCredentialFlags(cmd)
+
+	cmd.AddCommand(reportDiffCommand(c))
+
+	return cmd
+}
+
+func reportPath(id string) string {
+	return "runs/" + url.PathEscape(id) + "/report"
+}
+
+// fetchReport returns the report of a run, along with its JSON as sent by the
+// server.
+func fetchReport(c HTTPClient, creds credentials, id string) (*testReport, []byte, error) {
+	data, err := get(c, creds, reportPath(id), nil, "application/json")
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &testReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode report of test run %s: %w", id, err)
+	}
+	return report, data, nil
+}
+
+func prettyPrintReport(cmd *cobra.Command, report *testReport) {
+	s := report.summary()
+
+	cmd.Println(HeaderStyle.Render(fmt.Sprintf("Report of test run %s (%s)", report.RunID, report.Name)))
+	cmd.Printf("Properties: %s, %s, %s\n",
+		SuccessStyle.Render(fmt.Sprintf("%d passed", s.Passed)),
+		ErrorStyle.Render(fmt.Sprintf("%d failed", s.Failed)),
+		WarningStyle.Render(fmt.Sprintf("%d unfound", s.Unfound)))
+	if s.Failed > 0 {
+		cmd.Printf("Failures:   %s, %s\n",
+			ValueStyle.Render(fmt.Sprintf("%d new", s.NewFailures)),
+			ValueStyle.Render(fmt.Sprintf("%d recurring", s.RecurringFailures)))
+	}
+
+	if failed := report.filter(propertyFailed); len(failed) > 0 {
+		cmd.Printf("\n%s\n", ErrorStyle.Render("Failed properties:"))
+		for _, p := range failed {
+			marker := SubtleStyle.Render("recurring")
+			if p.New {
+				marker = WarningStyle.Render("new")
+			}
+			cmd.Printf("  ✗ %s %s\n", p.Name, marker)
+			if p.Message != "" {
+				cmd.Printf("    %s\n", SubtleStyle.Render(p.Message))
+			}
+		}
+	}
+	if unfound := report.filter(propertyUnfound); len(unfound) > 0 {
+		cmd.Printf("\n%s\n", WarningStyle.Render("Unfound properties:"))
+		for _, p := range unfound {
+			cmd.Printf("  ? %s\n", p.Name)
+		}
+	}
+	if report.ReportURL != "" {
+		cmd.Printf("\nFull report: %s\n", ValueStyle.Render(report.ReportURL))
+	}
+}
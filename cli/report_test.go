@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testReports = map[string]testReport{
+	"run-1": {
+		RunID:     "run-1",
+		Name:      "quickstart",
+		ReportURL: "https://tenant.antithesis.com/report/run-1",
+		Properties: []reportProperty{
+			{Name: "Orders are processed", Type: "Sometimes", Status: propertyPassed},
+			{Name: "Payments are idempotent", Type: "Always", Status: propertyPassed},
+			{Name: "Balance is never negative", Type: "Always", Status: propertyFailed, New: true, Message: "balance was -10", Examples: []string{"[payment] balance=-10"}, Location: &propertyLocation{File: "payment/balance.go", Line: 42}},
+			{Name: "No 500s", Type: "Always", Status: propertyFailed},
+			{Name: "Refunds are reached", Type: "Reachable", Status: propertyUnfound},
+		},
+	},
+	"run-2": {
+		RunID: "run-2",
+		Name:  "quickstart",
+		Properties: []reportProperty{
+			{Name: "Payments are idempotent", Type: "Always", Status: propertyFailed, New: true},
+			{Name: "Balance is never negative", Type: "Always", Status: propertyPassed},
+			{Name: "No 500s", Type: "Always", Status: propertyFailed},
+			{Name: "Refunds are reached", Type: "Reachable", Status: propertyPassed},
+			{Name: "Shipments are sent", Type: "Sometimes", Status: propertyPassed},
+		},
+	},
+}
+
+// fakeReportAPI serves the reports of testReports as JSON or HTML.
+func fakeReportAPI(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/runs/{id}/report", func(w http.ResponseWriter, r *http.Request) {
+		report, ok := testReports[r.PathValue("id")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error": "report not found"}`)
+			return
+		}
+		if r.Header.Get("Accept") == "text/html" {
+			io.WriteString(w, "<html>"+report.Name+"</html>")
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(report))
+	})
+	return mux
+}
+
+func TestReportCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	dir := t.TempDir()
+
+	tcs := []struct {
+		name           string
+		args           []string
+		expectedOutput []string
+		expectedFile   string
+		expectedSaved  string
+		expectedErr    string
+	}{
+		{
+			name: "Summary",
+			args: []string{"run-1"},
+			expectedOutput: []string{
+				"2 passed", "2 failed", "1 unfound",
+				"1 new", "1 recurring",
+				"Balance is never negative", "balance was -10",
+				"Refunds are reached",
+				"https://tenant.antithesis.com/report/run-1",
+			},
+		},
+		{
+			name:          "Save HTML",
+			args:          []string{"run-1", "--save=" + filepath.Join(dir, "report.html")},
+			expectedFile:  filepath.Join(dir, "report.html"),
+			expectedSaved: "<html>quickstart</html>",
+		},
+		{
+			name:          "Save JSON",
+			args:          []string{"run-1", "--save=" + filepath.Join(dir, "report.json")},
+			expectedFile:  filepath.Join(dir, "report.json"),
+			expectedSaved: `"run_id":"run-1"`,
+		},
+		{
+			name:        "Unknown run",
+			args:        []string{"run-3"},
+			expectedErr: "unexpected non-200 status code: 404: report not found",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := reportCommand(newFakeServerClient(t, fakeReportAPI(t)))
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range tc.expectedOutput {
+				assert.Contains(t, stdout.String(), s)
+			}
+			if tc.expectedFile != "" {
+				data, err := os.ReadFile(tc.expectedFile)
+				assert.NoError(t, err)
+				assert.Contains(t, string(data), tc.expectedSaved)
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		cmd := reportCommand(newFakeServerClient(t, fakeReportAPI(t)))
+		addGlobalFlags(cmd)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetArgs([]string{"run-1", "--output=json"})
+
+		assert.NoError(t, cmd.Execute())
+
+		result := struct {
+			testReport
+			Summary reportSummary `json:"summary"`
+		}{}
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Len(t, result.Properties, 5)
+		assert.Equal(t, reportSummary{Passed: 2, Failed: 2, Unfound: 1, NewFailures: 1, RecurringFailures: 1}, result.Summary)
+	})
+}
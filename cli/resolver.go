@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	projectConfigFileName = ".antithesis.yaml"
+)
+
+// configLayer is a source of config values, such as a config file.
+type configLayer struct {
+	// origin describes the layer for a given key, e.g. "flag --tenant".
+	origin func(key configKey) string
+	values map[string]string
+}
+
+// configResolver resolves the value of a config key by merging, from highest
+// to lowest precedence:
+//
+//  1. the command's flags,
+//  2. the ANTITHESIS_* environment variables,
+//  3. the project config file, found by walking up from the working directory,
+//  4. the user config file,
+//  5. the flags' default values.
+//
+// The tenant and username of the selected profile take the precedence of
+// where default.profile is set, just above it.
+type configResolver struct {
+	cmd    *cobra.Command
+	layers []configLayer
+
+	// profile is the origin of the values of the selected profile, if any.
+	profile string
+
+	// profileErr is set when the selected profile can't be loaded, and only
+	// reported to the commands that need credentials.
+	profileErr error
+}
+
+func newConfigResolver(cmd *cobra.Command) (*configResolver, error) {
+	r := &configResolver{cmd: cmd}
+
+	env := make(map[string]string)
+	for _, k := range configKeys {
+		if v := os.Getenv(k.Env); k.Env != "" && v != "" {
+			env[k.Name] = v
+		}
+	}
+	r.layers = append(r.layers, configLayer{
+		origin: func(k configKey) string { return "environment variable " + k.Env },
+		values: env,
+	})
+
+	if path, err := findProjectConfig(); err != nil {
+		return nil, err
+	} else if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		r.layers = append(r.layers, configLayer{
+			origin: func(configKey) string { return "project config " + path },
+			values: values,
+		})
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r.layers = append(r.layers, configLayer{
+		origin: func(configKey) string { return "user config " + path },
+		values: values,
+	})
+
+	// The values of the profile rank just above the layer selecting it, so
+	// that they override the tenant and username set next to the selection,
+	// but never the ones set with a higher precedence.
+	if name, _ := r.Get("default.profile"); name != "" {
+		profiles, err := loadProfiles()
+		if err != nil {
+			return nil, err
+		}
+		p, err := profiles.lookup(name)
+		if err != nil {
+			r.profileErr = err
+			return r, nil
+		}
+		r.profile = fmt.Sprintf("profile '%s'", name)
+		// A profile without a username leaves it to the stored credentials
+		// of its tenant.
+		values := map[string]string{"default.tenant": p.Tenant, "default.username": p.Username}
+		profile := configLayer{
+			origin: func(configKey) string { return r.profile },
+			values: values,
+		}
+		i := 0
+		if f := r.flag(lookupConfigKey("default.profile")); f == nil || !f.Changed {
+			i = slices.IndexFunc(r.layers, func(l configLayer) bool {
+				_, ok := l.values["default.profile"]
+				return ok
+			})
+			if i < 0 {
+				// Selected by the default value of the flag.
+				i = len(r.layers)
+			}
+		}
+		r.layers = slices.Insert(r.layers, i, profile)
+	}
+
+	return r, nil
+}
+
+// Get returns the value of key and where it comes from. The value is empty
+// when the key is not set anywhere and the command has no such flag.
+func (r *configResolver) Get(key string) (string, string) {
+	k := lookupConfigKey(key)
+	f := r.flag(k)
+	if f != nil && f.Changed {
+		return flagValue(f), "flag --" + f.Name
+	}
+	for _, layer := range r.layers {
+		if v, ok := layer.values[key]; ok {
+			return v, layer.origin(k)
+		}
+	}
+	if f != nil {
+		return flagValue(f), "default"
+	}
+	return "", ""
+}
+
+// Value returns the value of key, ignoring where it comes from.
+func (r *configResolver) Value(key string) string {
+	v, _ := r.Get(key)
+	return v
+}
+
+func (r *configResolver) flag(k configKey) *pflag.Flag {
+	if k.Flag == "" {
+		return nil
+	}
+	return r.cmd.Flags().Lookup(k.Flag)
+}
+
+func flagValue(f *pflag.Flag) string {
+	if s, ok := f.Value.(pflag.SliceValue); ok {
+		return strings.Join(s.GetSlice(), ",")
+	}
+	return f.Value.String()
+}
+
+// findProjectConfig walks up from the working directory looking for a project
+// config file. It returns an empty path if there is none.
+func findProjectConfig() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	for {
+		path := filepath.Join(dir, projectConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestConfigResolver(t *testing.T) {
+	cfg := setUserConfigDir(t)
+
+	project := t.TempDir()
+	nested := filepath.Join(project, "services", "api")
+	assert.NoError(t, os.MkdirAll(nested, 0755))
+	chdir(t, nested)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(cfg, configFileName), []byte(
+		"default:\n  tenant: user-tenant\n  username: user-username\n  notebook: user-notebook\n  duration: 20\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(project, projectConfigFileName), []byte(
+		"default:\n  tenant: project-tenant\n  notebook: project-notebook\n"), 0644))
+	t.Setenv("ANTITHESIS_NOTEBOOK", "env-notebook")
+
+	newCommand := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("tenant", "", "")
+		cmd.Flags().String("notebook", "basic_test", "")
+		cmd.Flags().String("profile", "", "")
+		cmd.Flags().StringArray("email", nil, "")
+		assert.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	tcs := []struct {
+		name   string
+		args   []string
+		key    string
+		value  string
+		origin string
+	}{
+		{
+			name:   "Flag",
+			args:   []string{"--notebook=flag-notebook"},
+			key:    "default.notebook",
+			value:  "flag-notebook",
+			origin: "flag --notebook",
+		},
+		{
+			name:   "Environment variable",
+			key:    "default.notebook",
+			value:  "env-notebook",
+			origin: "environment variable ANTITHESIS_NOTEBOOK",
+		},
+		{
+			name:   "Project config",
+			key:    "default.tenant",
+			value:  "project-tenant",
+			origin: "project config " + filepath.Join(project, projectConfigFileName),
+		},
+		{
+			name:   "User config",
+			key:    "default.username",
+			value:  "user-username",
+			origin: "user config " + filepath.Join(cfg, configFileName),
+		},
+		{
+			name:   "Profile",
+			args:   []string{"--profile=staging"},
+			key:    "default.tenant",
+			value:  "profile-tenant",
+			origin: "profile 'staging'",
+		},
+		{
+			name:   "Repeated flag",
+			args:   []string{"--email=a@example.com", "--email=b@example.com"},
+			key:    "default.email",
+			value:  "a@example.com,b@example.com",
+			origin: "flag --email",
+		},
+		{
+			name: "Unset",
+			key:  "build.registry",
+		},
+	}
+
+	profiles, err := loadProfiles()
+	assert.NoError(t, err)
+	profiles.Profiles["staging"] = profile{Tenant: "profile-tenant"}
+	assert.NoError(t, profiles.save())
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := newConfigResolver(newCommand(tc.args...))
+			assert.NoError(t, err)
+			value, origin := r.Get(tc.key)
+			assert.Equal(t, tc.value, value)
+			assert.Equal(t, tc.origin, origin)
+		})
+	}
+
+	t.Run("Profile over environment variables", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_TENANT", "env-tenant")
+		r, err := newConfigResolver(newCommand("--profile=staging"))
+		assert.NoError(t, err)
+		value, origin := r.Get("default.tenant")
+		assert.Equal(t, "profile-tenant", value)
+		assert.Equal(t, "profile 'staging'", origin)
+
+		// The username of the user config is meant for another tenant.
+		value, _ = r.Get("default.username")
+		assert.Empty(t, value)
+
+		r, err = newConfigResolver(newCommand("--profile=staging", "--tenant=flag-tenant"))
+		assert.NoError(t, err)
+		value, _ = r.Get("default.tenant")
+		assert.Equal(t, "flag-tenant", value)
+	})
+
+	t.Run("Profile from a config file", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, projectConfigFileName), []byte("default:\n  profile: staging\n"), 0644))
+		chdir(t, dir)
+
+		// The profile overrides the user config, but not the environment.
+		r, err := newConfigResolver(newCommand())
+		assert.NoError(t, err)
+		value, origin := r.Get("default.tenant")
+		assert.Equal(t, "profile-tenant", value)
+		assert.Equal(t, "profile 'staging'", origin)
+		value, _ = r.Get("default.username")
+		assert.Empty(t, value)
+
+		t.Setenv("ANTITHESIS_TENANT", "env-tenant")
+		t.Setenv("ANTITHESIS_USERNAME", "env-username")
+		t.Setenv("ANTITHESIS_PASSWORD", "env-password")
+		r, err = newConfigResolver(newCommand())
+		assert.NoError(t, err)
+		creds, _, err := resolveCredentials(r)
+		assert.NoError(t, err)
+		assert.Equal(t, credentials{Tenant: "env-tenant", Username: "env-username", Password: "env-password"}, creds)
+
+		// The password of the environment is meant for another tenant.
+		t.Setenv("ANTITHESIS_TENANT", "")
+		r, err = newConfigResolver(newCommand())
+		assert.NoError(t, err)
+		_, _, err = resolveCredentials(r)
+		assert.EqualError(t, err, "refusing to use the password from environment variable ANTITHESIS_PASSWORD with the tenant of profile 'staging': pass --tenant and --username along with it, or log into the profile with 'antithesis auth login'")
+	})
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		r, err := newConfigResolver(newCommand("--profile=unknown"))
+		assert.NoError(t, err)
+		_, _, err = resolveCredentials(r)
+		assert.Error(t, err)
+	})
+
+	t.Run("List with origins", func(t *testing.T) {
+		t.Setenv("ANTITHESIS_CREDENTIALS_STORE", "")
+		cmd := configCommand()
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetArgs([]string{"list", "--show-origin"})
+		assert.NoError(t, cmd.Execute())
+
+		userConfig := "user config " + filepath.Join(cfg, configFileName)
+		projectConfig := "project config " + filepath.Join(project, projectConfigFileName)
+		assert.Equal(t, userConfig+"\tdefault.duration=20\n"+
+			"environment variable ANTITHESIS_NOTEBOOK\tdefault.notebook=env-notebook\n"+
+			projectConfig+"\tdefault.tenant=project-tenant\n"+
+			userConfig+"\tdefault.username=user-username\n", stdout.String())
+	})
+}
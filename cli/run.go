@@ -4,35 +4,63 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/mail"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
 )
 
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-func runCommand(c HTTPClient) *cobra.Command {
+func runCommand(c HTTPClient, runner CommandRunner) *cobra.Command {
 	var (
-		name        string
-		notebook    string
-		tenant      string
-		description string
-		username    string
-		password    string
-		config      string
-		images      []string
-		duration    int32
-		emails      []string
+		name         string
+		notebook     string
+		tenant       string
+		description  string
+		username     string
+		password     string
+		config       string
+		images       []string
+		duration     int32
+		emails       []string
+		file         string
+		dryRun       bool
+		extraParams  []string
+		paramsFile   string
+		wait         bool
+		timeout      time.Duration
+		pollInterval time.Duration
+		build        bool
+		push         bool
+		project      string
+		tag          string
+		platform     string
 	)
 
+	// Required flags can also be set by the manifest, so they are checked once
+	// it is applied instead of by cobra.
+	requiredFlags := []string{
+		"name",
+		"config",
+		"image",
+	}
+
 	cmd := &cobra.Command{
 		Use:     "run [flags]",
-		Long:    "Run an antithesis test. Note: Before running this command, you must first build and push all required images to either a public container registry or to Antithesis' private registry.",
+		Long:    "Run an antithesis test. The test can be described with flags or with a YAML or JSON manifest passed with --file, in which case flags override the manifest's values. Note: Before running this command, you must first build and push all required images to either a public container registry or to Antithesis' private registry, e.g. with 'antithesis build' and 'antithesis push', or let --build do both and test the exact digests pushed. Without --config, the images pinned in the antithesis.lock of the project by 'antithesis push' are tested.",
 		Short:   "Run an antithesis test",
 		GroupID: "development",
 		Example: `
@@ -51,23 +79,145 @@ antithesis run \
   --image='docker.io/nats:latest' \
   --image='docker.io/stripemock/stripe-mock:latest' \
   --duration=15 \
-  --email='gguergabo@gmail.com'`,
+  --email='gguergabo@gmail.com'
+
+# Run a test described by a manifest, overriding its duration.
+antithesis run -f antithesis.yaml --duration=30
+
+# Run a test with extra notebook parameters.
+antithesis run -f antithesis.yaml --param='custom.source=ci' --params-file=params.yaml
+
+# Build and push the images of the project in the current directory, then test them.
+antithesis run --name='quickstart' --build --image='docker.io/postgres:16'
+
+# Test the images pinned by the last 'antithesis push' of the project in the current directory.
+antithesis run --name='quickstart' --image='docker.io/postgres:16'
+
+# Print the launch request of a test without running it.
+antithesis run -f antithesis.yaml --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
 
-			url := fmt.Sprintf("https://%s.antithesis.com/api/v1/launch_experiment/%s", tenant, notebook)
+			var manifest runManifest
+			if file != "" {
+				m, err := readRunManifest(file)
+				if err != nil {
+					return err
+				}
+				if err := m.apply(cmd); err != nil {
+					return err
+				}
+				manifest = *m
+			}
 
-			if duration < 15 {
-				return fmt.Errorf("duration can't be less than 15.")
+			// The images of the project are pushed by --build and --push.
+			pushProjectImages := build || push
+			if pushProjectImages && cmd.Flags().Changed("config") {
+				return fmt.Errorf("--config cannot be set with --build or --push, which use the config image of the project")
 			}
 
-			for _, email := range emails {
-				_, err := mail.ParseAddress(email)
+			// Without --config, the images pinned by the last 'antithesis push'
+			// of the project are tested.
+			locked := false
+			if !pushProjectImages && !cmd.Flags().Changed("config") {
+				lock, err := readLockfile(project)
 				if err != nil {
-					return fmt.Errorf("email not valid: %w", err)
+					return err
 				}
+				if lock != nil {
+					config = lock.Config
+					services := maps.Keys(lock.Services)
+					slices.Sort(services)
+					for _, service := range services {
+						images = append(images, lock.Services[service])
+					}
+					locked = true
+					cmd.PrintErrln(SubtleStyle.Render(fmt.Sprintf("Testing the images pinned in %s", filepath.Join(project, lockfileName))))
+				}
+			}
+
+			var missing []string
+			for _, flag := range requiredFlags {
+				if (pushProjectImages || locked) && (flag == "config" || flag == "image") {
+					continue
+				}
+				if !cmd.Flags().Changed(flag) {
+					missing = append(missing, flag)
+				}
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf(`required flag(s) "%s" not set`, strings.Join(missing, `", "`))
 			}
 
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+			notebook = r.Value("default.notebook")
+
+			// Everything is validated before the images are built and pushed.
+			duration, err = parseDuration(r.Value("default.duration"))
+			if err != nil {
+				return err
+			}
+			if wait && pollInterval <= 0 {
+				return fmt.Errorf("--poll-interval must be positive")
+			}
+
+			emails = splitList(r.Value("default.email"))
+			if len(emails) == 0 {
+				return fmt.Errorf("at least one email is required: pass --email or set the default.email config key")
+			}
+			if err := validateEmails(emails); err != nil {
+				return err
+			}
+
+			// Extra parameters override the built-in ones, in increasing order
+			// of precedence: manifest, params file and --param flags.
+			extra := make(map[string]string)
+			for key, value := range manifest.Params {
+				extra[key] = value
+			}
+			if paramsFile != "" {
+				fileParams, err := readParamsFile(paramsFile)
+				if err != nil {
+					return err
+				}
+				for key, value := range fileParams {
+					extra[key] = value
+				}
+			}
+			for _, param := range extraParams {
+				key, value, err := parseParam(param)
+				if err != nil {
+					return err
+				}
+				extra[key] = value
+			}
+
+			if pushProjectImages {
+				pinned, err := pushImagesForRun(cmd, runner, r, project, tag, platform, build, dryRun)
+				if err != nil {
+					return err
+				}
+				for _, image := range pinned {
+					if image.Config {
+						config = image.Digest
+					} else {
+						images = append(images, image.Digest)
+					}
+				}
+			}
+			url := tenantURL(creds.Tenant, "launch_experiment/"+notebook)
+
 			params := map[string]string{
 				"antithesis.test_name":         name,
 				"antithesis.config_image":      trimWhitespace(config),
@@ -77,6 +227,15 @@ antithesis run \
 				"antithesis.duration":          fmt.Sprintf("%d", duration),
 			}
 
+			keys := maps.Keys(extra)
+			slices.Sort(keys)
+			for _, key := range keys {
+				if builtin, ok := params[key]; ok && builtin != extra[key] {
+					cmd.PrintErrln(WarningStyle.Render(fmt.Sprintf("Warning: parameter %q overrides the value %q set by the CLI", key, builtin)))
+				}
+				params[key] = extra[key]
+			}
+
 			body := &struct {
 				Params map[string]string `json:"params"`
 			}{params}
@@ -91,9 +250,13 @@ antithesis run \
 				return fmt.Errorf("failed to create request: %v", err)
 			}
 
-			req.SetBasicAuth(username, password)
+			req.SetBasicAuth(creds.Username, creds.Password)
 			req.Header.Set("Content-Type", "application/json")
 
+			if dryRun {
+				return printDryRun(cmd, req, body, output)
+			}
+
 			resp, err := c.Do(req)
 			if err != nil {
 				return fmt.Errorf("failed to send request: %v", err)
@@ -101,64 +264,315 @@ antithesis run \
 			defer resp.Body.Close()
 
 			if resp.StatusCode != 200 {
-				switch resp.StatusCode {
-				case 403:
-					return fmt.Errorf("access forbidden (HTTP 403): please verify your tenant, username, and password are correct")
-				default:
-					return fmt.Errorf("unexpected non-200 status code: %d", resp.StatusCode)
-				}
+				return responseError(resp)
+			}
+
+			launched, err := decodeLaunchResponse(resp)
+			if err != nil {
+				// The run was launched anyway, so this must not fail the command.
+				cmd.PrintErrln(WarningStyle.Render(fmt.Sprintf("Warning: %v", err)))
+			}
+
+			submittedAt := time.Now()
+			result := &runResult{
+				TestName:    name,
+				Tenant:      creds.Tenant,
+				Notebook:    notebook,
+				SubmittedAt: submittedAt,
+				// Setting up the environment takes roughly 10 minutes.
+				EstimatedCompletion: submittedAt.Add(time.Duration(duration)*time.Minute + runSetupTime),
+				Recipients:          emails,
+				RunID:               launched.RunID,
+				SessionID:           launched.SessionID,
+				ReportURL:           launched.ReportURL,
+				Message:             launched.Message,
+			}
+			if !wait {
+				return printOutput(cmd, output, result, func() error {
+					prettyPrintRunOutput(cmd, result)
+					return nil
+				})
 			}
 
-			prettyPrintRunOutput(cmd, params, duration)
-			return nil
+			if output == textOutput {
+				prettyPrintRunOutput(cmd, result)
+				cmd.Println()
+			}
+			if result.RunID == "" {
+				return fmt.Errorf("cannot wait for the test run, the server did not return its ID")
+			}
+			// Ctrl-C offers to cancel the run instead of leaving it running.
+			interrupts := make(chan os.Signal, 1)
+			signal.Notify(interrupts, os.Interrupt)
+			defer signal.Stop(interrupts)
+			status, err := waitForRun(cmd, c, creds, result.RunID, timeout, pollInterval, interrupts)
+			if status == nil {
+				return err
+			}
+			result.Status = status
+			if err := printOutput(cmd, output, result, func() error {
+				prettyPrintRunStatus(cmd, status, time.Now())
+				return nil
+			}); err != nil {
+				return err
+			}
+			if err != nil {
+				return err
+			}
+			return runOutcome(status)
 		},
 	}
 
 	cmd.Flags().StringVarP(&name, "name", "n", "", "unique identifier for this test run")
 	cmd.Flags().StringVarP(&description, "description", "d", "", "description explaining the purpose of this test run")
-	cmd.Flags().StringVarP(&tenant, "tenant", "t", "", "target tenant ID for test execution")
-	cmd.Flags().StringVarP(&username, "username", "u", "", "authentication username for accessing test resources")
-	cmd.Flags().StringVarP(&password, "password", "p", "", "authentication password for accessing test resources")
+	cmd.Flags().StringVarP(&tenant, "tenant", "t", "", "target tenant ID for test execution (defaults to the tenant stored by 'antithesis auth login')")
+	cmd.Flags().StringVarP(&username, "username", "u", "", "authentication username for accessing test resources (defaults to the stored credentials)")
+	cmd.Flags().StringVarP(&password, "password", "p", "", "authentication password for accessing test resources (defaults to the stored credentials)")
 	cmd.Flags().StringVarP(&config, "config", "c", "", "url of configuration image containing docker-compose setup")
 	cmd.Flags().StringArrayVarP(&images, "image", "i", make([]string, 0), "list of image URLs to process during testing (can specify multiple)")
-	cmd.Flags().StringVarP(&notebook, "notebook", "b", "basic_test", "notebook to execute")
+	cmd.Flags().StringVarP(&notebook, "notebook", "b", "basic_test", "notebook to execute (defaults to the default.notebook config key)")
 	cmd.Flags().Int32VarP(&duration, "duration", "m", 15, "maximum test runtime in minutes (minimum is 15, the longer the deeper)")
-	cmd.Flags().StringArrayVarP(&emails, "email", "e", make([]string, 0), "email addresses to notify with test results (can specify multiple)")
+	cmd.Flags().StringArrayVarP(&emails, "email", "e", make([]string, 0), "email addresses to notify with test results (can specify multiple, defaults to the default.email config key)")
 
-	requiredFlags := []string{
-		"name",
-		"tenant",
-		"username",
-		"password",
-		"config",
-		"image",
-		"email",
+	cmd.Flags().StringVarP(&file, "file", "f", "", "path to a YAML or JSON run manifest, whose values are overridden by flags")
+	cmd.Flags().StringArrayVar(&extraParams, "param", make([]string, 0), "extra launch parameter as key=value (can specify multiple)")
+	cmd.Flags().StringVar(&paramsFile, "params-file", "", "path to a YAML or JSON file of extra launch parameters")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate the test run and print the launch request without sending it")
+	cmd.Flags().BoolVarP(&wait, "wait", "w", false, fmt.Sprintf("wait for the test run to complete, exiting with %d if it found failures, %d if it failed to run and %d if it timed out", exitTestFailures, exitInfrastructureError, exitTimeout))
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "maximum time to wait for the test run with --wait, e.g. 2h (0 waits forever)")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 30*time.Second, "time between two checks of the test run's status with --wait")
+	cmd.Flags().BoolVar(&build, "build", false, "build and push the images of the project, and test them instead of --config and alongside --image")
+	cmd.Flags().BoolVar(&push, "push", false, "push the images of the project built with 'antithesis build', and test them instead of --config and alongside --image")
+	cmd.Flags().StringVar(&project, "project", ".", "path to the project built with --build, pushed with --push or whose antithesis.lock is tested without --config")
+	cmd.Flags().StringVar(&tag, "tag", "", "tag of the images of the project (defaults to the short git commit of the project)")
+	// The registry and the engine are resolved with the config keys.
+	cmd.Flags().String("registry", "", "registry the images of the project are pushed to (defaults to the registry of the tenant)")
+	cmd.Flags().String("engine", "", fmt.Sprintf("container engine used to build and push the images of the project (%s, defaults to the one installed)", strings.Join(availableEngines, ", ")))
+	cmd.Flags().StringVar(&platform, "platform", defaultPlatform, "platform of the images built with --build")
+
+	return cmd
+}
+
+// pushImagesForRun builds the images of the project in dir when build is set,
+// and pushes them. It fails when any of them failed to push, so that a test
+// run never tests images other than the ones of the project. On a dry run,
+// nothing is built nor pushed, and the images are referenced by tag.
+func pushImagesForRun(cmd *cobra.Command, runner CommandRunner, r *configResolver, dir, tag, platform string, build, dryRun bool) ([]pushedImage, error) {
+	if dryRun {
+		registry, _, err := projectRegistry(r)
+		if err != nil {
+			return nil, err
+		}
+		result, err := resolveProjectImages(runner, dir, registry, tag)
+		if err != nil {
+			return nil, err
+		}
+		var images []pushedImage
+		for _, image := range result.Images {
+			images = append(images, pushedImage{Service: image.Service, Image: image.Image, Digest: image.Image, Config: image.Config})
+		}
+		return images, nil
 	}
 
-	for _, flag := range requiredFlags {
-		_ = cmd.MarkFlagRequired(flag)
+	engine, err := containerEngine(r)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := loginRegistry(cmd, runner, r, engine)
+	if err != nil {
+		return nil, err
+	}
+	result, err := resolveProjectImages(runner, dir, registry, tag)
+	if err != nil {
+		return nil, err
+	}
+	if build {
+		if err := buildImages(cmd, runner, engine, platform, result.Images); err != nil {
+			return nil, fmt.Errorf("refusing to launch the test run: %w", err)
+		}
 	}
+	pushed, err := pushProject(cmd, runner, engine, dir, result)
+	if err != nil {
+		for _, image := range pushed.Images {
+			if image.Error != "" {
+				cmd.PrintErrln(ErrorStyle.Render(image.Error))
+			}
+		}
+		return nil, fmt.Errorf("refusing to launch the test run: %w", err)
+	}
+	return pushed.Images, nil
+}
 
-	return cmd
+// runResult describes a submitted test run.
+type runResult struct {
+	TestName            string     `json:"test_name" yaml:"test_name"`
+	Tenant              string     `json:"tenant" yaml:"tenant"`
+	Notebook            string     `json:"notebook" yaml:"notebook"`
+	SubmittedAt         time.Time  `json:"submitted_at" yaml:"submitted_at"`
+	EstimatedCompletion time.Time  `json:"estimated_completion" yaml:"estimated_completion"`
+	Recipients          []string   `json:"recipients" yaml:"recipients"`
+	RunID               string     `json:"run_id,omitempty" yaml:"run_id,omitempty"`
+	SessionID           string     `json:"session_id,omitempty" yaml:"session_id,omitempty"`
+	ReportURL           string     `json:"report_url,omitempty" yaml:"report_url,omitempty"`
+	Message             string     `json:"message,omitempty" yaml:"message,omitempty"`
+	Status              *runStatus `json:"status,omitempty" yaml:"status,omitempty"`
 }
 
-func prettyPrintRunOutput(cmd *cobra.Command, params map[string]string, duration_mins int32) {
-	name := params["antithesis.test_name"]
-	recipients := params["antithesis.report.recipients"]
+// launchResponse is the body of a successful launch_experiment response. All
+// of its fields are optional.
+type launchResponse struct {
+	RunID     string `json:"run_id"`
+	SessionID string `json:"session_id"`
+	ReportURL string `json:"report_url"`
+	Message   string `json:"message"`
+}
 
-	duration := (time.Duration(duration_mins) * time.Minute) + (10 * time.Minute)
-	finishesApprox := time.Now().Add(duration)
+func decodeLaunchResponse(resp *http.Response) (*launchResponse, error) {
+	launched := &launchResponse{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return launched, fmt.Errorf("failed to read launch response: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return launched, nil
+	}
+	if err := json.Unmarshal(body, launched); err != nil {
+		return launched, fmt.Errorf("failed to decode launch response: %w", err)
+	}
+	return launched, nil
+}
+
+func prettyPrintRunOutput(cmd *cobra.Command, result *runResult) {
+	duration := result.EstimatedCompletion.Sub(result.SubmittedAt)
 
 	cmd.Printf("\n%s\n\n",
-		SuccessStyle.Render(fmt.Sprintf("Successfully submitted a request to launch test run '%s'!", name)))
+		SuccessStyle.Render(fmt.Sprintf("Successfully submitted a request to launch test run '%s'!", result.TestName)))
+	if result.Message != "" {
+		cmd.Printf("%s\n\n", result.Message)
+	}
+	if result.RunID != "" {
+		cmd.Printf("Run ID:     %s\n", ValueStyle.Render(result.RunID))
+	}
+	if result.SessionID != "" {
+		cmd.Printf("Session ID: %s\n", ValueStyle.Render(result.SessionID))
+	}
+	if result.ReportURL != "" {
+		cmd.Printf("Report:     %s\n", ValueStyle.Render(result.ReportURL))
+	}
+	if result.RunID != "" || result.SessionID != "" || result.ReportURL != "" {
+		cmd.Println()
+	}
 	cmd.Printf("You should receive a test report emailed to %s around %s.\n",
-		ValueStyle.Render(recipients),
-		ValueStyle.Render(finishesApprox.Format("Jan 2 3:04PM")))
+		ValueStyle.Render(strings.Join(result.Recipients, ";")),
+		ValueStyle.Render(result.EstimatedCompletion.Format("Jan 2 3:04PM")))
 	cmd.Printf("(Thats roughly %s from now including setup)\n\n", ValueStyle.Render(duration.String()))
 	cmd.Printf("If you encounter any issues, use %s to reach out.\n",
 		ValueStyle.Render("Antithesis' discord"))
 }
 
+// printDryRun prints the request that would be sent, without its credentials.
+func printDryRun(cmd *cobra.Command, req *http.Request, body any, output string) error {
+	headers := make(map[string]string, len(req.Header))
+	for key := range req.Header {
+		headers[key] = req.Header.Get(key)
+	}
+	headers["Authorization"] = "Basic <redacted>"
+
+	dryRun := &struct {
+		Method  string            `json:"method" yaml:"method"`
+		URL     string            `json:"url" yaml:"url"`
+		Headers map[string]string `json:"headers" yaml:"headers"`
+		Body    any               `json:"body" yaml:"body"`
+	}{req.Method, req.URL.String(), headers, body}
+
+	return printOutput(cmd, output, dryRun, func() error {
+		prettyBody, err := json.MarshalIndent(body, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %v", err)
+		}
+		cmd.Println(SubtleStyle.Render("Dry run, the following request would be sent:"))
+		cmd.Printf("%s %s\n", req.Method, req.URL)
+		keys := maps.Keys(headers)
+		slices.Sort(keys)
+		for _, key := range keys {
+			cmd.Printf("%s: %s\n", key, headers[key])
+		}
+		cmd.Printf("\n%s\n", prettyBody)
+		return nil
+	})
+}
+
+// parseParam parses a key=value launch parameter.
+func parseParam(param string) (string, string, error) {
+	key, value, ok := strings.Cut(param, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return "", "", fmt.Errorf("parameter %q not valid: must be key=value", param)
+	}
+	return strings.TrimSpace(key), value, nil
+}
+
+// readParamsFile reads a flat YAML or JSON object of launch parameters.
+func readParamsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read params file: %w", err)
+	}
+	// YAML is a superset of JSON, so both are parsed the same way.
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse params file %s: %w", path, err)
+	}
+	params := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("failed to parse params file %s: value of %q must be a scalar", path, key)
+		case nil:
+			params[key] = ""
+		default:
+			params[key] = fmt.Sprint(value)
+		}
+	}
+	return params, nil
+}
+
+func parseDuration(value string) (int32, error) {
+	duration, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("duration not valid: %w", err)
+	}
+	if duration < 15 {
+		return 0, fmt.Errorf("duration can't be less than 15.")
+	}
+	return int32(duration), nil
+}
+
+func validateDuration(value string) error {
+	_, err := parseDuration(value)
+	return err
+}
+
+func validateEmails(emails []string) error {
+	for _, email := range emails {
+		_, err := mail.ParseAddress(email)
+		if err != nil {
+			return fmt.Errorf("email not valid: %w", err)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func trimWhitespace(in string) string {
 	return strings.ReplaceAll(in, " ", "")
 }
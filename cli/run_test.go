@@ -2,25 +2,34 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 type MockHttpClient struct {
 	resp *http.Response
 	err  error
+	req  *http.Request
 }
 
 func NewMockHttpClient(resp *http.Response, err error) HTTPClient {
 	return &MockHttpClient{
-		resp,
-		err,
+		resp: resp,
+		err:  err,
 	}
 }
 
 func (c *MockHttpClient) Do(req *http.Request) (*http.Response, error) {
+	c.req = req
 	return c.resp, c.err
 }
 
@@ -33,6 +42,8 @@ type TestCase struct {
 }
 
 func TestRunCommand(t *testing.T) {
+	setUserConfigDir(t)
+
 	tcs := []TestCase{
 		// {
 		// 	name: "Valid request",
@@ -91,7 +102,7 @@ func TestRunCommand(t *testing.T) {
 	}
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			run := runCommand(tc.mockClient)
+			run := runCommand(tc.mockClient, &fakeRunner{})
 			stdout := &bytes.Buffer{}
 			run.SetOut(stdout)
 			run.SetArgs(tc.args)
@@ -106,3 +117,808 @@ func TestRunCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestRunCommandStoredCredentials(t *testing.T) {
+	setUserConfigDir(t)
+
+	f, err := loadCredentials()
+	assert.NoError(t, err)
+	assert.NoError(t, f.set(credentials{Tenant: "tenant", Username: "user", Password: "pass"}, fileStore))
+	assert.NoError(t, f.save())
+
+	mockClient := NewMockHttpClient(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+	run := runCommand(mockClient, &fakeRunner{})
+	run.SetOut(&bytes.Buffer{})
+	run.SetArgs([]string{
+		"--name=quickstart",
+		"--config=config",
+		"--image=image1",
+		"--email=email1@gmail.com",
+	})
+
+	assert.NoError(t, run.Execute())
+	req := mockClient.(*MockHttpClient).req
+	assert.Equal(t, "https://tenant.antithesis.com/api/v1/launch_experiment/basic_test", req.URL.String())
+	username, password, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+}
+
+func TestRunCommandManifest(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	dir := t.TempDir()
+	yamlManifest := filepath.Join(dir, "antithesis.yaml")
+	assert.NoError(t, os.WriteFile(yamlManifest, []byte(`
+name: quickstart
+description: desc
+notebook: custom_test
+config: config
+images:
+  - image1
+  - image2
+duration: 20
+recipients:
+  - email1@gmail.com
+params:
+  custom.source: ci
+`), 0644))
+	jsonManifest := filepath.Join(dir, "antithesis.json")
+	assert.NoError(t, os.WriteFile(jsonManifest, []byte(`{"name": "quickstart", "image": ["typo"]}`), 0644))
+
+	tcs := []struct {
+		name     string
+		args     []string
+		url      string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "Manifest",
+			args: []string{"-f", yamlManifest},
+			url:  "https://tenant.antithesis.com/api/v1/launch_experiment/custom_test",
+			expected: map[string]string{
+				"antithesis.test_name":         "quickstart",
+				"antithesis.description":       "desc",
+				"antithesis.config_image":      "config",
+				"antithesis.images":            "image1;image2",
+				"antithesis.duration":          "20",
+				"antithesis.report.recipients": "email1@gmail.com",
+				"custom.source":                "ci",
+			},
+		},
+		{
+			name: "Flags override the manifest",
+			args: []string{"-f", yamlManifest, "--duration=30", "--image=image3", "--notebook=basic_test"},
+			url:  "https://tenant.antithesis.com/api/v1/launch_experiment/basic_test",
+			expected: map[string]string{
+				"antithesis.test_name":         "quickstart",
+				"antithesis.description":       "desc",
+				"antithesis.config_image":      "config",
+				"antithesis.images":            "image3",
+				"antithesis.duration":          "30",
+				"antithesis.report.recipients": "email1@gmail.com",
+				"custom.source":                "ci",
+			},
+		},
+		{
+			name:    "Unknown manifest field",
+			args:    []string{"-f", jsonManifest},
+			wantErr: true,
+		},
+		{
+			name:    "Missing manifest",
+			args:    []string{"-f", filepath.Join(dir, "missing.yaml")},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+			run := runCommand(mockClient, &fakeRunner{})
+			run.SetOut(&bytes.Buffer{})
+			run.SetErr(&bytes.Buffer{})
+			run.SetArgs(tc.args)
+
+			err := run.Execute()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			req := mockClient.(*MockHttpClient).req
+			assert.Equal(t, tc.url, req.URL.String())
+			body := struct {
+				Params map[string]string `json:"params"`
+			}{}
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			assert.Equal(t, tc.expected, body.Params)
+		})
+	}
+}
+
+func TestRunCommandDryRun(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "secret")
+
+	args := []string{
+		"--name=quickstart",
+		"--description=desc",
+		"--config=config",
+		"--image=image1",
+		"--email=email1@gmail.com",
+		"--dry-run",
+	}
+
+	tcs := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "Text",
+			expected: "Dry run, the following request would be sent:\n" +
+				"POST https://tenant.antithesis.com/api/v1/launch_experiment/basic_test\n" +
+				"Authorization: Basic <redacted>\n" +
+				"Content-Type: application/json\n" +
+				"\n" +
+				"{\n" +
+				"  \"params\": {\n" +
+				"    \"antithesis.config_image\": \"config\",\n" +
+				"    \"antithesis.description\": \"desc\",\n" +
+				"    \"antithesis.duration\": \"15\",\n" +
+				"    \"antithesis.images\": \"image1\",\n" +
+				"    \"antithesis.report.recipients\": \"email1@gmail.com\",\n" +
+				"    \"antithesis.test_name\": \"quickstart\"\n" +
+				"  }\n" +
+				"}\n",
+		},
+		{
+			name: "JSON",
+			args: []string{"--output=json"},
+			expected: `{
+  "method": "POST",
+  "url": "https://tenant.antithesis.com/api/v1/launch_experiment/basic_test",
+  "headers": {
+    "Authorization": "Basic <redacted>",
+    "Content-Type": "application/json"
+  },
+  "body": {
+    "params": {
+      "antithesis.config_image": "config",
+      "antithesis.description": "desc",
+      "antithesis.duration": "15",
+      "antithesis.images": "image1",
+      "antithesis.report.recipients": "email1@gmail.com",
+      "antithesis.test_name": "quickstart"
+    }
+  }
+}
+`,
+		},
+		{
+			name:    "Validation still happens",
+			args:    []string{"--duration=10"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown output",
+			args:    []string{"--output=xml"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := NewMockHttpClient(nil, nil)
+			run := runCommand(mockClient, &fakeRunner{})
+			addGlobalFlags(run)
+			stdout := &bytes.Buffer{}
+			run.SetOut(stdout)
+			run.SetErr(&bytes.Buffer{})
+			run.SetArgs(append(args, tc.args...))
+
+			err := run.Execute()
+			assert.Nil(t, mockClient.(*MockHttpClient).req, "a dry run must not send any request")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, stdout.String())
+			assert.NotContains(t, stdout.String(), "secret")
+		})
+	}
+}
+
+func TestRunCommandParams(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	dir := t.TempDir()
+	paramsFile := filepath.Join(dir, "params.yaml")
+	assert.NoError(t, os.WriteFile(paramsFile, []byte("custom.source: file\ncustom.retries: 3\n"), 0644))
+	nestedParamsFile := filepath.Join(dir, "nested.json")
+	assert.NoError(t, os.WriteFile(nestedParamsFile, []byte(`{"custom": {"source": "file"}}`), 0644))
+
+	args := []string{
+		"--name=quickstart",
+		"--config=config",
+		"--image=image1",
+		"--email=email1@gmail.com",
+	}
+
+	tcs := []struct {
+		name     string
+		args     []string
+		expected map[string]string
+		warning  string
+		wantErr  bool
+	}{
+		{
+			name: "Flags and file",
+			args: []string{"--params-file=" + paramsFile, "--param=custom.source=flag", "--param=custom.tag=a=b"},
+			expected: map[string]string{
+				"custom.source":  "flag",
+				"custom.retries": "3",
+				"custom.tag":     "a=b",
+			},
+		},
+		{
+			name:     "Shadowing a built-in parameter",
+			args:     []string{"--param=antithesis.duration=60"},
+			expected: map[string]string{"antithesis.duration": "60"},
+			warning:  `Warning: parameter "antithesis.duration" overrides the value "15" set by the CLI`,
+		},
+		{
+			name:    "Invalid parameter",
+			args:    []string{"--param=custom.source"},
+			wantErr: true,
+		},
+		{
+			name:    "Nested params file",
+			args:    []string{"--params-file=" + nestedParamsFile},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+			run := runCommand(mockClient, &fakeRunner{})
+			stderr := &bytes.Buffer{}
+			run.SetOut(&bytes.Buffer{})
+			run.SetErr(stderr)
+			run.SetArgs(append(args, tc.args...))
+
+			err := run.Execute()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			body := struct {
+				Params map[string]string `json:"params"`
+			}{}
+			assert.NoError(t, json.NewDecoder(mockClient.(*MockHttpClient).req.Body).Decode(&body))
+			for key, value := range tc.expected {
+				assert.Equal(t, value, body.Params[key])
+			}
+			if tc.warning != "" {
+				assert.Contains(t, stderr.String(), tc.warning)
+			} else {
+				assert.Empty(t, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunCommandOutput(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	args := []string{
+		"--name=quickstart",
+		"--config=config",
+		"--image=image1",
+		"--email=email1@gmail.com",
+		"--email=email2@gmail.com",
+		"--duration=20",
+	}
+
+	tcs := []struct {
+		name   string
+		output string
+		decode func([]byte, any) error
+	}{
+		{
+			name:   "JSON",
+			output: "json",
+			decode: json.Unmarshal,
+		},
+		{
+			name:   "YAML",
+			output: "yaml",
+			decode: yaml.Unmarshal,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"run_id": "run-1"}`))}, nil)
+			run := runCommand(mockClient, &fakeRunner{})
+			addGlobalFlags(run)
+			stdout := &bytes.Buffer{}
+			run.SetOut(stdout)
+			run.SetArgs(append(args, "--output="+tc.output))
+
+			assert.NoError(t, run.Execute())
+
+			result := runResult{}
+			assert.NoError(t, tc.decode(stdout.Bytes(), &result))
+			assert.Equal(t, "quickstart", result.TestName)
+			assert.Equal(t, "tenant", result.Tenant)
+			assert.Equal(t, "basic_test", result.Notebook)
+			assert.Equal(t, []string{"email1@gmail.com", "email2@gmail.com"}, result.Recipients)
+			assert.Equal(t, "run-1", result.RunID)
+			assert.Equal(t, 30*time.Minute, result.EstimatedCompletion.Sub(result.SubmittedAt))
+		})
+	}
+
+	t.Run("Unknown output", func(t *testing.T) {
+		run := runCommand(NewMockHttpClient(nil, nil), &fakeRunner{})
+		addGlobalFlags(run)
+		run.SetOut(&bytes.Buffer{})
+		run.SetErr(&bytes.Buffer{})
+		run.SetArgs(append(args, "--output=xml"))
+		assert.Error(t, run.Execute())
+	})
+}
+
+func TestRunCommandResponse(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	args := []string{
+		"--name=quickstart",
+		"--config=config",
+		"--image=image1",
+		"--email=email1@gmail.com",
+	}
+
+	tcs := []struct {
+		name           string
+		statusCode     int
+		body           string
+		expectedErr    string
+		expectedOutput []string
+		expectedStderr string
+	}{
+		{
+			name:           "Run and session IDs",
+			statusCode:     200,
+			body:           `{"run_id": "run-1", "session_id": "session-1", "report_url": "https://tenant.antithesis.com/report/run-1"}`,
+			expectedOutput: []string{"run-1", "session-1", "https://tenant.antithesis.com/report/run-1"},
+		},
+		{
+			name:           "Empty body",
+			statusCode:     200,
+			body:           "",
+			expectedOutput: []string{"Successfully submitted"},
+		},
+		{
+			name:           "Invalid body",
+			statusCode:     200,
+			body:           "not json",
+			expectedOutput: []string{"Successfully submitted"},
+			expectedStderr: "failed to decode launch response",
+		},
+		{
+			name:        "JSON error",
+			statusCode:  400,
+			body:        `{"error": "unknown notebook 'basic_test'"}`,
+			expectedErr: "unexpected non-200 status code: 400: unknown notebook 'basic_test'",
+		},
+		{
+			name:        "Plain text error",
+			statusCode:  403,
+			body:        "account suspended\n",
+			expectedErr: "access forbidden (HTTP 403): please verify your tenant, username, and password are correct: account suspended",
+		},
+		{
+			name:        "HTML error",
+			statusCode:  502,
+			body:        "<html><body>Bad Gateway</body></html>",
+			expectedErr: "unexpected non-200 status code: 502",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: tc.statusCode, Body: io.NopCloser(strings.NewReader(tc.body))}, nil)
+			run := runCommand(mockClient, &fakeRunner{})
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			run.SetOut(stdout)
+			run.SetErr(stderr)
+			run.SetArgs(args)
+
+			err := run.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range tc.expectedOutput {
+				assert.Contains(t, stdout.String(), s)
+			}
+			if tc.expectedStderr != "" {
+				assert.Contains(t, stderr.String(), tc.expectedStderr)
+			}
+		})
+	}
+}
+
+func TestRunCommandWait(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	args := []string{
+		"--name=quickstart",
+		"--config=config",
+		"--image=image1",
+		"--email=email1@gmail.com",
+		"--wait",
+		"--poll-interval=1ms",
+	}
+
+	running := runStatus{ID: "run-1", Name: "quickstart", State: runRunning, SubmittedAt: time.Now()}
+	withState := func(state string, failedProperties int) runStatus {
+		status := running
+		status.State = state
+		status.FailedProperties = failedProperties
+		return status
+	}
+
+	tcs := []struct {
+		name             string
+		args             []string
+		launchBody       string
+		statuses         []runStatus
+		expectedErr      string
+		expectedExitCode int
+		expectedOutput   string
+	}{
+		{
+			name:           "Passed",
+			launchBody:     `{"run_id": "run-1"}`,
+			statuses:       []runStatus{withState(runQueued, 0), running, withState(runAnalyzing, 0), withState(runComplete, 0)},
+			expectedOutput: "passed",
+		},
+		{
+			name:             "Found failures",
+			launchBody:       `{"run_id": "run-1"}`,
+			statuses:         []runStatus{running, withState(runComplete, 2)},
+			expectedErr:      "test run run-1 found 2 failing properties",
+			expectedExitCode: exitTestFailures,
+			expectedOutput:   "2 failing properties",
+		},
+		{
+			name:             "Infrastructure error",
+			launchBody:       `{"run_id": "run-1"}`,
+			statuses:         []runStatus{withState(runSetup, 0), withState(runFailed, 0)},
+			expectedErr:      "test run run-1 failed to run",
+			expectedExitCode: exitInfrastructureError,
+		},
+		{
+			name:             "Timed out",
+			args:             []string{"--timeout=50ms"},
+			launchBody:       `{"run_id": "run-1"}`,
+			statuses:         []runStatus{running},
+			expectedErr:      "timed out after 50ms waiting for test run run-1",
+			expectedExitCode: exitTimeout,
+		},
+		{
+			name:             "Missing run ID",
+			launchBody:       "",
+			expectedErr:      "cannot wait for the test run, the server did not return its ID",
+			expectedExitCode: exitError,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /api/v1/launch_experiment/basic_test", func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tc.launchBody)
+			})
+			mux.HandleFunc("GET /api/v1/runs/run-1", func(w http.ResponseWriter, r *http.Request) {
+				status := tc.statuses[min(polls, len(tc.statuses)-1)]
+				polls++
+				assert.NoError(t, json.NewEncoder(w).Encode(status))
+			})
+
+			run := runCommand(newFakeServerClient(t, mux), &fakeRunner{})
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			run.SetOut(stdout)
+			run.SetErr(stderr)
+			run.SetArgs(append(args, tc.args...))
+
+			err := run.Execute()
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				assert.Equal(t, tc.expectedExitCode, ExitCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.expectedOutput != "" {
+				assert.Contains(t, stdout.String(), tc.expectedOutput)
+			}
+			if len(tc.statuses) > 0 {
+				assert.Contains(t, stderr.String(), "Test run run-1 is "+tc.statuses[0].State)
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /api/v1/launch_experiment/basic_test", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"run_id": "run-1"}`)
+		})
+		mux.HandleFunc("GET /api/v1/runs/run-1", func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewEncoder(w).Encode(withState(runComplete, 0)))
+		})
+
+		run := runCommand(newFakeServerClient(t, mux), &fakeRunner{})
+		addGlobalFlags(run)
+		stdout := &bytes.Buffer{}
+		run.SetOut(stdout)
+		run.SetErr(&bytes.Buffer{})
+		run.SetArgs(append(args, "--output=json"))
+
+		assert.NoError(t, run.Execute())
+
+		result := runResult{}
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		if assert.NotNil(t, result.Status) {
+			assert.Equal(t, runComplete, result.Status.State)
+		}
+	})
+}
+
+func TestRunCommandBuild(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "acme")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+	t.Setenv("ANTITHESIS_BUILD_ENGINE", "docker")
+	t.Setenv("ANTITHESIS_REGISTRY", "")
+
+	registry := "us-central1-docker.pkg.dev/molten-verve-216720/acme-repository"
+
+	tcs := []struct {
+		name             string
+		args             []string
+		fail             string
+		expectedConfig   string
+		expectedImages   string
+		expectedCommands []string
+		expectedErr      string
+	}{
+		{
+			name:           "Build",
+			args:           []string{"--build", "--image=docker.io/postgres:16"},
+			expectedConfig: registry + "/config@sha256:v1",
+			expectedImages: "docker.io/postgres:16;" + registry + "/order@sha256:v1",
+			expectedCommands: []string{
+				"docker login --username user --password-stdin us-central1-docker.pkg.dev",
+				"docker build --platform linux/amd64 --tag " + registry + "/config:v1 --file - {dir}/config",
+				"docker build --platform linux/amd64 --tag " + registry + "/order:v1 --file {dir}/order/Dockerfile {dir}/order",
+				"docker push " + registry + "/config:v1",
+				"docker image inspect --format {{json .RepoDigests}} " + registry + "/config:v1",
+				"docker push " + registry + "/order:v1",
+				"docker image inspect --format {{json .RepoDigests}} " + registry + "/order:v1",
+			},
+		},
+		{
+			name:           "Push",
+			args:           []string{"--push"},
+			expectedConfig: registry + "/config@sha256:v1",
+			expectedImages: registry + "/order@sha256:v1",
+		},
+		{
+			name:           "Registry and platform",
+			args:           []string{"--build", "--registry=docker.io/acme", "--platform=linux/arm64"},
+			expectedConfig: "docker.io/acme/config@sha256:v1",
+			expectedImages: "docker.io/acme/order@sha256:v1",
+			expectedCommands: []string{
+				"docker build --platform linux/arm64 --tag docker.io/acme/config:v1 --file - {dir}/config",
+				"docker build --platform linux/arm64 --tag docker.io/acme/order:v1 --file {dir}/order/Dockerfile {dir}/order",
+				"docker push docker.io/acme/config:v1",
+				"docker image inspect --format {{json .RepoDigests}} docker.io/acme/config:v1",
+				"docker push docker.io/acme/order:v1",
+				"docker image inspect --format {{json .RepoDigests}} docker.io/acme/order:v1",
+			},
+		},
+		{
+			name:        "Push failure",
+			args:        []string{"--build"},
+			fail:        "push " + registry + "/order",
+			expectedErr: "refusing to launch the test run: failed to push 1 of 2 images",
+		},
+		{
+			name:        "Build failure",
+			args:        []string{"--build"},
+			fail:        "build",
+			expectedErr: "refusing to launch the test run: failed to build " + registry + "/config:v1: exit status 1",
+		},
+		{
+			name:        "Config",
+			args:        []string{"--build", "--config=config"},
+			expectedErr: "--config cannot be set with --build or --push, which use the config image of the project",
+		},
+		{
+			name:        "Invalid duration",
+			args:        []string{"--build", "--duration=10"},
+			expectedErr: "duration can't be less than 15.",
+		},
+		{
+			name:        "Invalid email",
+			args:        []string{"--build", "--email=email2"},
+			expectedErr: "email not valid: mail: missing '@' or angle-addr",
+		},
+		{
+			name:        "Invalid parameter",
+			args:        []string{"--push", "--param=custom.source"},
+			expectedErr: `parameter "custom.source" not valid: must be key=value`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeProject(t, "config/docker-compose.yaml", "order/Dockerfile")
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+			runner := &fakeRunner{fail: tc.fail}
+			run := runCommand(mockClient, runner)
+			run.SetOut(&bytes.Buffer{})
+			run.SetErr(&bytes.Buffer{})
+			run.SetArgs(append([]string{"--name=quickstart", "--email=email1@gmail.com", "--project=" + dir, "--tag=v1"}, tc.args...))
+
+			err := run.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.Nil(t, mockClient.(*MockHttpClient).req, "the test run must not be launched")
+				if tc.fail == "" {
+					assert.Empty(t, runner.commands, "invalid flags must fail before anything is built or pushed")
+				}
+				return
+			}
+			assert.NoError(t, err)
+
+			body := struct {
+				Params map[string]string `json:"params"`
+			}{}
+			assert.NoError(t, json.NewDecoder(mockClient.(*MockHttpClient).req.Body).Decode(&body))
+			assert.Equal(t, tc.expectedConfig, body.Params["antithesis.config_image"])
+			assert.Equal(t, tc.expectedImages, body.Params["antithesis.images"])
+			if tc.expectedCommands != nil {
+				for i := range tc.expectedCommands {
+					tc.expectedCommands[i] = strings.ReplaceAll(tc.expectedCommands[i], "{dir}", dir)
+				}
+				assert.Equal(t, tc.expectedCommands, runner.commands)
+			}
+			_, err = os.Stat(filepath.Join(dir, lockfileName))
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		// Nothing is built nor pushed, so no container engine is needed.
+		t.Setenv("ANTITHESIS_BUILD_ENGINE", "")
+		t.Setenv("PATH", t.TempDir())
+		dir := writeProject(t, "config/docker-compose.yaml", "order/Dockerfile")
+		runner := &fakeRunner{}
+		run := runCommand(NewMockHttpClient(nil, nil), runner)
+		stdout := &bytes.Buffer{}
+		run.SetOut(stdout)
+		run.SetErr(&bytes.Buffer{})
+		run.SetArgs([]string{"--name=quickstart", "--email=email1@gmail.com", "--project=" + dir, "--build", "--dry-run"})
+
+		assert.NoError(t, run.Execute())
+		assert.Equal(t, []string{"git rev-parse --short HEAD"}, runner.commands)
+		assert.Contains(t, stdout.String(), registry+"/config:abc1234")
+		assert.Contains(t, stdout.String(), registry+"/order:abc1234")
+	})
+}
+
+func TestRunCommandLockfile(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "acme")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+	t.Setenv("ANTITHESIS_BUILD_ENGINE", "docker")
+	t.Setenv("ANTITHESIS_REGISTRY", "")
+
+	registry := "us-central1-docker.pkg.dev/molten-verve-216720/acme-repository"
+
+	tcs := []struct {
+		name           string
+		lockfile       string
+		args           []string
+		expectedConfig string
+		expectedImages string
+		expectedErr    string
+	}{
+		{
+			name:           "Pushed project",
+			args:           []string{"--image=docker.io/postgres:16"},
+			expectedConfig: registry + "/config@sha256:v1",
+			expectedImages: "docker.io/postgres:16;" + registry + "/order@sha256:v1;" + registry + "/payment@sha256:v1",
+		},
+		{
+			name:           "Config",
+			args:           []string{"--config=config", "--image=image1"},
+			expectedConfig: "config",
+			expectedImages: "image1",
+		},
+		{
+			name:        "No lockfile",
+			lockfile:    "none",
+			expectedErr: `required flag(s) "config", "image" not set`,
+		},
+		{
+			name:        "No config image",
+			lockfile:    "tag: v1\n",
+			expectedErr: "failed to parse lockfile {dir}/antithesis.lock: no config image, push the project again",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeProject(t, "config/docker-compose.yaml", "order/Dockerfile", "payment/Dockerfile")
+			switch tc.lockfile {
+			case "":
+				push := pushCommand(&fakeRunner{})
+				push.SetOut(&bytes.Buffer{})
+				push.SetErr(&bytes.Buffer{})
+				push.SetArgs([]string{dir, "--tag=v1"})
+				assert.NoError(t, push.Execute())
+			case "none":
+			default:
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, lockfileName), []byte(tc.lockfile), 0644))
+			}
+
+			mockClient := NewMockHttpClient(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+			runner := &fakeRunner{}
+			run := runCommand(mockClient, runner)
+			run.SetOut(&bytes.Buffer{})
+			run.SetErr(&bytes.Buffer{})
+			run.SetArgs(append([]string{"--name=quickstart", "--email=email1@gmail.com", "--project=" + dir}, tc.args...))
+
+			err := run.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, strings.ReplaceAll(tc.expectedErr, "{dir}", dir))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Empty(t, runner.commands, "the images of the lockfile must not be built nor pushed")
+
+			body := struct {
+				Params map[string]string `json:"params"`
+			}{}
+			assert.NoError(t, json.NewDecoder(mockClient.(*MockHttpClient).req.Body).Decode(&body))
+			assert.Equal(t, tc.expectedConfig, body.Params["antithesis.config_image"])
+			assert.Equal(t, tc.expectedImages, body.Params["antithesis.images"])
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// runSetupTime is roughly how long a test run spends outside of testing,
+	// setting up the images and analyzing the results.
+	runSetupTime = 10 * time.Minute
+)
+
+const (
+	runQueued    = "queued"
+	runSetup     = "setup"
+	runRunning   = "running"
+	runAnalyzing = "analyzing"
+	runComplete  = "complete"
+	runFailed    = "failed"
+	runCancelled = "cancelled"
+)
+
+var (
+	runStates = []string{runQueued, runSetup, runRunning, runAnalyzing, runComplete, runFailed, runCancelled}
+)
+
+// runStatus is a test run as reported by the tenant's API.
+type runStatus struct {
+	ID                  string     `json:"id" yaml:"id"`
+	Name                string     `json:"name" yaml:"name"`
+	Notebook            string     `json:"notebook,omitempty" yaml:"notebook,omitempty"`
+	State               string     `json:"state" yaml:"state"`
+	Duration            int32      `json:"duration,omitempty" yaml:"duration,omitempty"`
+	SubmittedAt         time.Time  `json:"submitted_at" yaml:"submitted_at"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty" yaml:"estimated_completion,omitempty"`
+	ReportURL           string     `json:"report_url,omitempty" yaml:"report_url,omitempty"`
+	FailedProperties    int        `json:"failed_properties,omitempty" yaml:"failed_properties,omitempty"`
+	Message             string     `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// done reports whether the run reached a final state.
+func (s *runStatus) done() bool {
+	return s.State == runComplete || s.State == runFailed || s.State == runCancelled
+}
+
+// elapsed returns the time since the run was submitted, or how long it took
+// once it is done.
+func (s *runStatus) elapsed(now time.Time) time.Duration {
+	end := now
+	if s.CompletedAt != nil {
+		end = *s.CompletedAt
+	}
+	return end.Sub(s.SubmittedAt).Round(time.Second)
+}
+
+// eta returns when the run should complete, preferring the estimate of the
+// server over the one computed from its duration.
+func (s *runStatus) eta() (time.Time, bool) {
+	if s.done() {
+		return time.Time{}, false
+	}
+	if s.EstimatedCompletion != nil {
+		return *s.EstimatedCompletion, true
+	}
+	if s.Duration > 0 {
+		return s.SubmittedAt.Add(time.Duration(s.Duration)*time.Minute + runSetupTime), true
+	}
+	return time.Time{}, false
+}
+
+func runsCommand(c HTTPClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "runs",
+		Long:    "Inspect the test runs of your tenant",
+		Short:   "Inspect the test runs of your tenant",
+		GroupID: "development",
+		Example: `
+# Inspect the test runs of your tenant
+antithesis runs [list | status | cancel]
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(runsListCommand(c))
+	cmd.AddCommand(runsStatusCommand(c))
+	cmd.AddCommand(runsCancelCommand(c))
+
+	return cmd
+}
+
+func runsListCommand(c HTTPClient) *cobra.Command {
+	var (
+		name  string
+		state string
+		since string
+		until string
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Long:  "List the recent test runs of your tenant, most recent first",
+		Short: "List the recent test runs",
+		Example: `
+# List the failed runs of the quickstart test since October 1st
+antithesis runs list --name=quickstart --state=failed --since=2024-10-01
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			query := url.Values{}
+			query.Set("limit", strconv.Itoa(limit))
+			if name != "" {
+				query.Set("name", name)
+			}
+			if state != "" {
+				if !slices.Contains(runStates, state) {
+					return fmt.Errorf("state %q is not supported, available states are: %s", state, strings.Join(runStates, ", "))
+				}
+				query.Set("state", state)
+			}
+			for key, value := range map[string]string{"since": since, "until": until} {
+				if value == "" {
+					continue
+				}
+				t, err := parseDate(value)
+				if err != nil {
+					return fmt.Errorf("invalid --%s: %w", key, err)
+				}
+				query.Set(key, t.Format(time.RFC3339))
+			}
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			list := struct {
+				Runs []runStatus `json:"runs" yaml:"runs"`
+			}{}
+			if err := getJSON(c, creds, "runs", query, &list); err != nil {
+				return err
+			}
+
+			return printOutput(cmd, output, list.Runs, func() error {
+				if len(list.Runs) == 0 {
+					cmd.Println("No runs found.")
+					return nil
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tNAME\tSTATE\tSUBMITTED\tELAPSED")
+				now := time.Now()
+				for _, run := range list.Runs {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", run.ID, run.Name, run.State, run.SubmittedAt.Local().Format("Jan 2 3:04PM"), run.elapsed(now))
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&name, "name", "n", "", "only list the runs of the test with this name")
+	cmd.Flags().StringVarP(&state, "state", "s", "", fmt.Sprintf("only list the runs in this state (%s)", strings.Join(runStates, ", ")))
+	cmd.Flags().StringVar(&since, "since", "", "only list the runs submitted after this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&until, "until", "", "only list the runs submitted before this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().IntVarP(&limit, "limit", "l", 20, "maximum number of runs to list")
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+func runsStatusCommand(c HTTPClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status <run-id>",
+		Long:  "Print the state of a test run, how long it has been running and when it should complete",
+		Short: "Print the status of a test run",
+		Example: `
+# Print the status of a test run
+antithesis runs status 3f2a9c
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			output, err := outputFormat(r)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			status, err := fetchRunStatus(c, creds, args[0])
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			result := struct {
+				runStatus `yaml:",inline"`
+				Elapsed   string     `json:"elapsed" yaml:"elapsed"`
+				ETA       *time.Time `json:"eta,omitempty" yaml:"eta,omitempty"`
+			}{runStatus: *status, Elapsed: status.elapsed(now).String()}
+			if eta, ok := status.eta(); ok {
+				result.ETA = &eta
+			}
+
+			return printOutput(cmd, output, result, func() error {
+				prettyPrintRunStatus(cmd, status, now)
+				return nil
+			})
+		},
+	}
+
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+func runsCancelCommand(c HTTPClient) *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "cancel <run-id>",
+		Long:  "Cancel a test run that is still in progress, stopping its experiment",
+		Short: "Cancel a test run",
+		Example: `
+# Cancel a test run without confirmation
+antithesis runs cancel 3f2a9c --yes
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			r, err := newConfigResolver(cmd)
+			if err != nil {
+				return err
+			}
+			creds, _, err := resolveCredentials(r)
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				ok, err := confirm(cmd, fmt.Sprintf("Do you want to cancel test run %s?", args[0]))
+				if err != nil {
+					return fmt.Errorf("no confirmation received to cancel test run %s, pass --yes to cancel it without confirmation: %w", args[0], err)
+				}
+				if !ok {
+					return nil
+				}
+			}
+			if err := cancelRun(c, creds, args[0]); err != nil {
+				return err
+			}
+			cmd.Println(SuccessStyle.Render(fmt.Sprintf("Test run %s has been cancelled", args[0])))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "cancel without asking for confirmation")
+	addCredentialFlags(cmd)
+
+	return cmd
+}
+
+// fetchRunStatus returns the current status of a run.
+func fetchRunStatus(c HTTPClient, creds credentials, id string) (*runStatus, error) {
+	status := &runStatus{}
+	if err := getJSON(c, creds, "runs/"+url.PathEscape(id), nil, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// cancelRun stops the experiment of a run.
+func cancelRun(c HTTPClient, creds credentials, id string) error {
+	return postJSON(c, creds, "runs/"+url.PathEscape(id)+"/cancel", nil, nil)
+}
+
+func prettyPrintRunStatus(cmd *cobra.Command, status *runStatus, now time.Time) {
+	cmd.Printf("Run:       %s %s\n", ValueStyle.Render(status.ID), SubtleStyle.Render(fmt.Sprintf("(%s)", status.Name)))
+	cmd.Printf("State:     %s\n", stateStyle(status.State).Render(status.State))
+	cmd.Printf("Submitted: %s\n", ValueStyle.Render(status.SubmittedAt.Local().Format("Jan 2 3:04PM")))
+	cmd.Printf("Elapsed:   %s\n", ValueStyle.Render(status.elapsed(now).String()))
+	if eta, ok := status.eta(); ok {
+		remaining := eta.Sub(now).Round(time.Minute)
+		if remaining < 0 {
+			remaining = 0
+		}
+		cmd.Printf("ETA:       %s %s\n", ValueStyle.Render(eta.Local().Format("Jan 2 3:04PM")), SubtleStyle.Render(fmt.Sprintf("(in %s)", remaining)))
+	}
+	if status.State == runComplete {
+		if status.FailedProperties > 0 {
+			cmd.Printf("Result:    %s\n", ErrorStyle.Render(fmt.Sprintf("%d failing properties", status.FailedProperties)))
+		} else {
+			cmd.Printf("Result:    %s\n", SuccessStyle.Render("passed"))
+		}
+	}
+	if status.ReportURL != "" {
+		cmd.Printf("Report:    %s\n", ValueStyle.Render(status.ReportURL))
+	}
+	if status.Message != "" {
+		cmd.Printf("\n%s\n", status.Message)
+	}
+}
+
+func stateStyle(state string) lipgloss.Style {
+	switch state {
+	case runComplete:
+		return SuccessStyle
+	case runFailed:
+		return ErrorStyle
+	default:
+		return ValueStyle
+	}
+}
+
+// parseDate parses a date given on the command line, either as YYYY-MM-DD in
+// the local time zone or as an RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date or an RFC 3339 timestamp", value)
+	}
+	return t, nil
+}
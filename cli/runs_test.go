@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServerClient sends the requests to the tenant's API to a local fake
+// server instead.
+type fakeServerClient struct {
+	server *httptest.Server
+}
+
+func newFakeServerClient(t *testing.T, handler http.Handler) HTTPClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &fakeServerClient{server: server}
+}
+
+func (c *fakeServerClient) Do(req *http.Request) (*http.Response, error) {
+	u, err := url.Parse(c.server.URL)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	return c.server.Client().Do(req)
+}
+
+// fakeRunsAPI serves the runs endpoints from a fixed set of runs.
+func fakeRunsAPI(t *testing.T, runs []runStatus, queries chan<- url.Values) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/runs", func(w http.ResponseWriter, r *http.Request) {
+		if queries != nil {
+			queries <- r.URL.Query()
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]any{"runs": runs}))
+	})
+	mux.HandleFunc("GET /api/v1/runs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, run := range runs {
+			if run.ID == r.PathValue("id") {
+				assert.NoError(t, json.NewEncoder(w).Encode(run))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"error": "run %s not found"}`, r.PathValue("id"))
+	})
+	return mux
+}
+
+func TestRunsCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	submittedAt := time.Now().Add(-20 * time.Minute).UTC().Truncate(time.Second)
+	completedAt := submittedAt.Add(25 * time.Minute)
+	runs := []runStatus{
+		{ID: "run-2", Name: "quickstart", State: runRunning, Duration: 15, SubmittedAt: submittedAt},
+		{ID: "run-1", Name: "quickstart", State: runComplete, Duration: 15, SubmittedAt: submittedAt.Add(-time.Hour), CompletedAt: &completedAt, ReportURL: "https://tenant.antithesis.com/report/run-1"},
+	}
+
+	tcs := []struct {
+		name            string
+		args            []string
+		expectedOutput  []string
+		expectedQuery   url.Values
+		expectedErr     string
+		unexpectedQuery bool
+	}{
+		{
+			name:           "List",
+			args:           []string{"list"},
+			expectedOutput: []string{"ID", "run-2", "run-1", runRunning, runComplete},
+			expectedQuery:  url.Values{"limit": {"20"}},
+		},
+		{
+			name:           "List filters",
+			args:           []string{"list", "--name=quickstart", "--state=failed", "--since=2024-10-01T00:00:00Z", "--limit=5"},
+			expectedOutput: []string{"run-2"},
+			expectedQuery:  url.Values{"limit": {"5"}, "name": {"quickstart"}, "state": {"failed"}, "since": {"2024-10-01T00:00:00Z"}},
+		},
+		{
+			name:            "List invalid state",
+			args:            []string{"list", "--state=done"},
+			expectedErr:     `state "done" is not supported, available states are: queued, setup, running, analyzing, complete, failed, cancelled`,
+			unexpectedQuery: true,
+		},
+		{
+			name:            "List invalid date",
+			args:            []string{"list", "--until=yesterday"},
+			expectedErr:     `invalid --until: "yesterday" is not a YYYY-MM-DD date or an RFC 3339 timestamp`,
+			unexpectedQuery: true,
+		},
+		{
+			name:           "Status running",
+			args:           []string{"status", "run-2"},
+			expectedOutput: []string{"run-2", runRunning, "Elapsed:", "ETA:", "(in 5m0s)"},
+		},
+		{
+			name:           "Status complete",
+			args:           []string{"status", "run-1"},
+			expectedOutput: []string{"run-1", runComplete, "1h25m0s", "https://tenant.antithesis.com/report/run-1"},
+		},
+		{
+			name:        "Status unknown run",
+			args:        []string{"status", "run-3"},
+			expectedErr: "unexpected non-200 status code: 404: run run-3 not found",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			queries := make(chan url.Values, 1)
+			cmd := runsCommand(newFakeServerClient(t, fakeRunsAPI(t, runs, queries)))
+			stdout := &bytes.Buffer{}
+			cmd.SetOut(stdout)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, s := range tc.expectedOutput {
+				assert.Contains(t, stdout.String(), s)
+			}
+			if tc.unexpectedQuery {
+				assert.Empty(t, queries)
+			}
+			if tc.expectedQuery != nil {
+				assert.Equal(t, tc.expectedQuery, <-queries)
+			}
+		})
+	}
+
+	t.Run("Status JSON", func(t *testing.T) {
+		cmd := runsCommand(newFakeServerClient(t, fakeRunsAPI(t, runs, nil)))
+		addGlobalFlags(cmd)
+		stdout := &bytes.Buffer{}
+		cmd.SetOut(stdout)
+		cmd.SetArgs([]string{"status", "run-2", "--output=json"})
+
+		assert.NoError(t, cmd.Execute())
+
+		result := struct {
+			runStatus
+			Elapsed string     `json:"elapsed"`
+			ETA     *time.Time `json:"eta"`
+		}{}
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, "run-2", result.ID)
+		assert.Equal(t, runRunning, result.State)
+		assert.NotEmpty(t, result.Elapsed)
+		if assert.NotNil(t, result.ETA) {
+			assert.True(t, result.ETA.Equal(submittedAt.Add(25*time.Minute)))
+		}
+	})
+}
+
+// fakeCancelAPI serves a run that stays running until it is cancelled.
+func fakeCancelAPI(t *testing.T, cancelled *bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/runs/run-1", func(w http.ResponseWriter, r *http.Request) {
+		state := runRunning
+		if *cancelled {
+			state = runCancelled
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(runStatus{ID: "run-1", State: state, SubmittedAt: time.Now()}))
+	})
+	mux.HandleFunc("POST /api/v1/runs/run-1/cancel", func(w http.ResponseWriter, r *http.Request) {
+		*cancelled = true
+	})
+	return mux
+}
+
+func TestRunsCancelCommand(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_TENANT", "tenant")
+	t.Setenv("ANTITHESIS_USERNAME", "user")
+	t.Setenv("ANTITHESIS_PASSWORD", "pass")
+
+	tcs := []struct {
+		name              string
+		args              []string
+		input             string
+		expectedCancelled bool
+		expectedErr       string
+	}{
+		{
+			name:              "Confirmed",
+			args:              []string{"cancel", "run-1"},
+			input:             "yes\n",
+			expectedCancelled: true,
+		},
+		{
+			name:              "Not confirmed",
+			args:              []string{"cancel", "run-1"},
+			input:             "no\n",
+			expectedCancelled: false,
+		},
+		{
+			name:        "No answer",
+			args:        []string{"cancel", "run-1"},
+			input:       "",
+			expectedErr: "no confirmation received to cancel test run run-1, pass --yes to cancel it without confirmation",
+		},
+		{
+			name:              "Yes flag",
+			args:              []string{"cancel", "run-1", "--yes"},
+			expectedCancelled: true,
+		},
+		{
+			name:        "Unknown run",
+			args:        []string{"cancel", "run-2", "--yes"},
+			expectedErr: "unexpected non-200 status code: 404",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cancelled := false
+			cmd := runsCommand(newFakeServerClient(t, fakeCancelAPI(t, &cancelled)))
+			cmd.SetIn(bytes.NewBufferString(tc.input))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tc.args)
+
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				assert.False(t, cancelled)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCancelled, cancelled)
+		})
+	}
+}
+
+func TestWaitForRunInterrupt(t *testing.T) {
+	creds := credentials{Tenant: "tenant", Username: "user", Password: "pass"}
+
+	tcs := []struct {
+		name              string
+		input             string
+		expectedCancelled bool
+		expectedErr       string
+	}{
+		{
+			name:              "Cancel",
+			input:             "yes\n",
+			expectedCancelled: true,
+			expectedErr:       "test run run-1 has been cancelled",
+		},
+		{
+			name:        "No answer",
+			input:       "",
+			expectedErr: "interrupted while waiting for test run run-1, which is still running: cancel it with 'antithesis runs cancel run-1'",
+		},
+		{
+			name:        "Keep waiting",
+			input:       "no\n",
+			expectedErr: "timed out after 50ms waiting for test run run-1",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			cancelled := false
+			c := newFakeServerClient(t, fakeCancelAPI(t, &cancelled))
+			cmd := &cobra.Command{}
+			cmd.SetIn(bytes.NewBufferString(tc.input))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+
+			interrupts := make(chan os.Signal, 1)
+			interrupts <- os.Interrupt
+			_, err := waitForRun(cmd, c, creds, "run-1", 50*time.Millisecond, time.Millisecond, interrupts)
+			assert.EqualError(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expectedCancelled, cancelled)
+		})
+	}
+
+	t.Run("Interrupted again", func(t *testing.T) {
+		cancelled := false
+		c := newFakeServerClient(t, fakeCancelAPI(t, &cancelled))
+		cmd := &cobra.Command{}
+		// Nobody ever answers.
+		stdin, w := io.Pipe()
+		t.Cleanup(func() { w.Close() })
+		cmd.SetIn(stdin)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+
+		interrupts := make(chan os.Signal, 2)
+		interrupts <- os.Interrupt
+		interrupts <- os.Interrupt
+		_, err := waitForRun(cmd, c, creds, "run-1", time.Minute, time.Millisecond, interrupts)
+		assert.EqualError(t, err, "interrupted while waiting for test run run-1, which is still running: cancel it with 'antithesis runs cancel run-1'")
+		assert.False(t, cancelled)
+	})
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIF 2.1.0, as uploaded to code scanning UIs such as GitHub's. Only the
+// parts of the format used by the CLI are described.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string          `json:"id"`
+	ShortDescription sarifMessage    `json:"shortDescription"`
+	FullDescription  *sarifMessage   `json:"fullDescription,omitempty"`
+	HelpURI          string          `json:"helpUri,omitempty"`
+	Properties       *sarifRuleProps `json:"properties,omitempty"`
+}
+
+type sarifRuleProps struct {
+	Tags []string `json:"tags"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// writeSARIF writes the failed properties of a report as SARIF, with one rule
+// and one result per property. Failed Always and AlwaysOrUnreachable
+// assertions are errors, the other failures warnings.
+func writeSARIF(w io.Writer, report *testReport) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "Antithesis",
+			Version:        version(),
+			InformationURI: "https://antithesis.com",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	// Properties may share a name, e.g. the same assertion message in two
+	// places, and then share a rule.
+	rules := make(map[string]int)
+	for _, p := range report.filter(propertyFailed) {
+		rule := sarifRule{
+			ID:               p.Name,
+			ShortDescription: sarifMessage{Text: p.Name},
+			HelpURI:          report.ReportURL,
+		}
+		if p.Description != "" {
+			rule.FullDescription = &sarifMessage{Text: p.Description}
+		}
+		if p.Type != "" {
+			rule.Properties = &sarifRuleProps{Tags: []string{p.Type}}
+		}
+		ruleIndex, ok := rules[rule.ID]
+		if !ok {
+			ruleIndex = len(run.Tool.Driver.Rules)
+			rules[rule.ID] = ruleIndex
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+
+		level := "warning"
+		if p.Type == "Always" || p.Type == "AlwaysOrUnreachable" {
+			level = "error"
+		}
+		message := fmt.Sprintf("Antithesis property failed: %s", p.Name)
+		if p.Message != "" {
+			message += "\n\n" + p.Message
+		}
+		if report.ReportURL != "" {
+			message += "\n\nSee the full report at " + report.ReportURL
+		}
+		result := sarifResult{
+			RuleID:    rule.ID,
+			RuleIndex: ruleIndex,
+			Level:     level,
+			Message:   sarifMessage{Text: message},
+		}
+		if p.Location != nil && p.Location.File != "" {
+			result.Locations = []sarifLocation{sarifPropertyLocation(p.Location)}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(log); err != nil {
+		return fmt.Errorf("failed to encode SARIF report: %w", err)
+	}
+	return nil
+}
+
+// sarifPropertyLocation turns the location of an assertion into a SARIF
+// location. Absolute paths become file URIs, while relative paths are
+// resolved against the root of the repository by code scanning UIs.
+func sarifPropertyLocation(l *propertyLocation) sarifLocation {
+	path := filepath.ToSlash(l.File)
+	var artifact sarifArtifactLocation
+	if isAbsPath(path) {
+		if !strings.HasPrefix(path, "/") {
+			// Windows paths, e.g. C:/src/main.go.
+			path = "/" + path
+		}
+		artifact.URI = (&url.URL{Scheme: "file", Path: path}).String()
+	} else {
+		artifact.URI = (&url.URL{Path: path}).String()
+		artifact.URIBaseID = "%SRCROOT%"
+	}
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact}}
+	if l.Line > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: l.Line}
+	}
+	return loc
+}
+
+// isAbsPath reports whether a slash-separated path is absolute on any OS, as
+// assertions may come from another OS than the one running the CLI.
+func isAbsPath(path string) bool {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") {
+		return true
+	}
+	return len(path) >= 3 && path[1] == ':' && path[2] == '/' &&
+		('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z')
+}
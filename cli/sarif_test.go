@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	report := testReports["run-1"]
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, writeSARIF(out, &report))
+
+	log := sarifLog{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	if !assert.Len(t, log.Runs, 1) {
+		return
+	}
+	run := log.Runs[0]
+	assert.Equal(t, "Antithesis", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, 2)
+	if !assert.Len(t, run.Results, 2) {
+		return
+	}
+
+	located := run.Results[0]
+	assert.Equal(t, "Balance is never negative", located.RuleID)
+	assert.Equal(t, 0, located.RuleIndex)
+	assert.Equal(t, "error", located.Level)
+	assert.Contains(t, located.Message.Text, "balance was -10")
+	assert.Contains(t, located.Message.Text, "https://tenant.antithesis.com/report/run-1")
+	assert.Equal(t, []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "payment/balance.go", URIBaseID: "%SRCROOT%"},
+		Region:           &sarifRegion{StartLine: 42},
+	}}}, located.Locations)
+
+	unlocated := run.Results[1]
+	assert.Equal(t, "No 500s", unlocated.RuleID)
+	assert.Equal(t, 1, unlocated.RuleIndex)
+	assert.Empty(t, unlocated.Locations)
+}
+
+func TestWriteSARIFNoFailures(t *testing.T) {
+	report := testReport{RunID: "run-2", Properties: []reportProperty{{Name: "No 500s", Type: "Always", Status: propertyPassed}}}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, writeSARIF(out, &report))
+	assert.Contains(t, out.String(), `"results": []`)
+}
+
+func TestSARIFPropertyLocation(t *testing.T) {
+	tcs := []struct {
+		file         string
+		expectedURI  string
+		expectedBase string
+	}{
+		{file: "payment/balance.go", expectedURI: "payment/balance.go", expectedBase: "%SRCROOT%"},
+		{file: "/src/payment/balance.go", expectedURI: "file:///src/payment/balance.go"},
+		{file: "C:/src/payment/balance.go", expectedURI: "file:///C:/src/payment/balance.go"},
+		{file: "/src/my service/main.go", expectedURI: "file:///src/my%20service/main.go"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.file, func(t *testing.T) {
+			loc := sarifPropertyLocation(&propertyLocation{File: tc.file})
+			assert.Equal(t, tc.expectedURI, loc.PhysicalLocation.ArtifactLocation.URI)
+			assert.Equal(t, tc.expectedBase, loc.PhysicalLocation.ArtifactLocation.URIBaseID)
+		})
+	}
+}
+
+func TestWriteSARIFDuplicateNames(t *testing.T) {
+	report := testReport{RunID: "run-3", Properties: []reportProperty{
+		{Name: "No 500s", Type: "Always", Status: propertyFailed, Location: &propertyLocation{File: "order/api.go", Line: 10}},
+		{Name: "No 500s", Type: "Always", Status: propertyFailed, Location: &propertyLocation{File: "payment/api.go", Line: 20}},
+	}}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, writeSARIF(out, &report))
+
+	log := sarifLog{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, 1)
+	if assert.Len(t, log.Runs[0].Results, 2) {
+		assert.Equal(t, 0, log.Runs[0].Results[0].RuleIndex)
+		assert.Equal(t, 0, log.Runs[0].Results[1].RuleIndex)
+	}
+}
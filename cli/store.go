@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	fileStore          = "file"
+	keyringStore       = "keyring"
+	encryptedFileStore = "encrypted-file"
+
+	encryptedCredentialsFileName = "credentials.enc"
+)
+
+var (
+	availableStores = []string{keyringStore, encryptedFileStore, fileStore}
+
+	errSecretNotFound = errors.New("secret not found")
+)
+
+// secretStore keeps the passwords of the tenants the user logged in to. The
+// rest of the credentials are not secret and always live in the credentials
+// file.
+type secretStore interface {
+	Get(tenant string) (string, error)
+	Set(tenant, password string) error
+	Delete(tenant string) error
+}
+
+// newSecretStore returns the backend called name. The file backend keeps the
+// passwords in the credentials file f itself.
+func newSecretStore(name string, f *credentialsFile) (secretStore, error) {
+	switch name {
+	case fileStore:
+		return &plaintextSecretStore{f}, nil
+	case keyringStore:
+		return &keyringSecretStore{}, nil
+	case encryptedFileStore:
+		return newEncryptedSecretStore()
+	default:
+		return nil, fmt.Errorf("credential store %q is not supported, available stores are: %s", name, strings.Join(availableStores, ", "))
+	}
+}
+
+// defaultSecretStore picks the backend used by 'antithesis auth login' when
+// none is configured: the OS keyring when there is one, the plaintext file
+// otherwise.
+func defaultSecretStore() string {
+	if keyringAvailable() {
+		return keyringStore
+	}
+	return fileStore
+}
+
+// plaintextSecretStore keeps the passwords unencrypted in the credentials
+// file, which is only readable by its owner.
+type plaintextSecretStore struct {
+	f *credentialsFile
+}
+
+func (s *plaintextSecretStore) Get(tenant string) (string, error) {
+	c, ok := s.f.Tenants[tenant]
+	if !ok || c.Password == "" {
+		return "", errSecretNotFound
+	}
+	return c.Password, nil
+}
+
+func (s *plaintextSecretStore) Set(tenant, password string) error {
+	c := s.f.Tenants[tenant]
+	c.Password = password
+	s.f.Tenants[tenant] = c
+	return nil
+}
+
+func (s *plaintextSecretStore) Delete(tenant string) error {
+	c, ok := s.f.Tenants[tenant]
+	if ok {
+		c.Password = ""
+		s.f.Tenants[tenant] = c
+	}
+	return nil
+}
+
+// encryptedSecretStore keeps the passwords in a file encrypted with AES-GCM,
+// using a key derived from the ANTITHESIS_CREDENTIALS_PASSPHRASE environment
+// variable. It is meant for headless machines without an OS keyring. Each
+// password is encrypted on its own, so that a tenant can be forgotten without
+// the passphrase.
+type encryptedSecretStore struct {
+	path       string
+	passphrase string
+}
+
+type encryptedFile struct {
+	Salt []byte `json:"salt"`
+	// Tenants maps each tenant to its encrypted password.
+	Tenants map[string]encryptedSecret `json:"tenants"`
+}
+
+type encryptedSecret struct {
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func newEncryptedSecretStore() (*encryptedSecretStore, error) {
+	cfg, err := getUserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return &encryptedSecretStore{
+		path:       filepath.Join(cfg, encryptedCredentialsFileName),
+		passphrase: os.Getenv("ANTITHESIS_CREDENTIALS_PASSPHRASE"),
+	}, nil
+}
+
+func (s *encryptedSecretStore) Get(tenant string) (string, error) {
+	f, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := f.Tenants[tenant]
+	if !ok {
+		return "", errSecretNotFound
+	}
+	aead, err := s.cipher(f.Salt)
+	if err != nil {
+		return "", err
+	}
+	// The tenant is authenticated along with the password, so that the
+	// password of a tenant can't be passed off as another's.
+	password, err := aead.Open(nil, secret.Nonce, secret.Ciphertext, []byte(tenant))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credentials: wrong ANTITHESIS_CREDENTIALS_PASSPHRASE?")
+	}
+	return string(password), nil
+}
+
+func (s *encryptedSecretStore) Set(tenant, password string) error {
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	if f.Salt == nil {
+		f.Salt = make([]byte, 16)
+		if _, err := rand.Read(f.Salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	aead, err := s.cipher(f.Salt)
+	if err != nil {
+		return err
+	}
+	// Make sure the passphrase is the one the other passwords are encrypted
+	// with, or they could never be decrypted again.
+	for other, secret := range f.Tenants {
+		if _, err := aead.Open(nil, secret.Nonce, secret.Ciphertext, []byte(other)); err != nil {
+			return fmt.Errorf("failed to decrypt credentials: wrong ANTITHESIS_CREDENTIALS_PASSPHRASE?")
+		}
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	f.Tenants[tenant] = encryptedSecret{
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, []byte(password), []byte(tenant)),
+	}
+	return s.save(f)
+}
+
+// Delete forgets the password of tenant, which doesn't need the passphrase.
+func (s *encryptedSecretStore) Delete(tenant string) error {
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(f.Tenants, tenant)
+	if len(f.Tenants) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove encrypted credentials: %w", err)
+		}
+		return nil
+	}
+	return s.save(f)
+}
+
+func (s *encryptedSecretStore) load() (*encryptedFile, error) {
+	f := &encryptedFile{}
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read encrypted credentials: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("failed to parse encrypted credentials %s: %w", s.path, err)
+		}
+	}
+	if f.Tenants == nil {
+		f.Tenants = make(map[string]encryptedSecret)
+	}
+	return f, nil
+}
+
+func (s *encryptedSecretStore) save(f *encryptedFile) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to marshal encrypted credentials: %w", err)
+	}
+	return writeFileAtomic(s.path, data, 0600)
+}
+
+func (s *encryptedSecretStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if s.passphrase == "" {
+		return nil, fmt.Errorf("the %s credential store requires the ANTITHESIS_CREDENTIALS_PASSPHRASE environment variable", encryptedFileStore)
+	}
+	key, err := scrypt.Key([]byte(s.passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
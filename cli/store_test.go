@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptedSecretStore(t *testing.T) {
+	cfg := setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "passphrase")
+
+	store, err := newEncryptedSecretStore()
+	assert.NoError(t, err)
+
+	_, err = store.Get("tenant")
+	assert.ErrorIs(t, err, errSecretNotFound)
+
+	assert.NoError(t, store.Set("tenant", "pass"))
+	assert.NoError(t, store.Set("other", "secret"))
+	password, err := store.Get("tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, "pass", password)
+
+	data, err := os.ReadFile(filepath.Join(cfg, encryptedCredentialsFileName))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "pass")
+	assert.NotContains(t, string(data), "secret")
+
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "wrong")
+	wrong, err := newEncryptedSecretStore()
+	assert.NoError(t, err)
+	_, err = wrong.Get("tenant")
+	assert.Error(t, err)
+
+	assert.NoError(t, store.Delete("tenant"))
+	assert.NoError(t, store.Delete("other"))
+	assert.NoFileExists(t, filepath.Join(cfg, encryptedCredentialsFileName))
+}
+
+func TestEncryptedSecretStoreRequiresPassphrase(t *testing.T) {
+	cfg := setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "passphrase")
+	store, err := newEncryptedSecretStore()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Set("tenant", "pass"))
+	assert.NoError(t, store.Set("other", "secret"))
+
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "")
+	store, err = newEncryptedSecretStore()
+	assert.NoError(t, err)
+	_, err = store.Get("tenant")
+	assert.EqualError(t, err, "the encrypted-file credential store requires the ANTITHESIS_CREDENTIALS_PASSPHRASE environment variable")
+	assert.Error(t, store.Set("tenant", "pass"))
+
+	// Passwords can be forgotten without the passphrase.
+	assert.NoError(t, store.Delete("tenant"))
+	assert.NoError(t, store.Delete("other"))
+	assert.NoFileExists(t, filepath.Join(cfg, encryptedCredentialsFileName))
+}
+
+func TestEncryptedSecretStoreWrongPassphrase(t *testing.T) {
+	setUserConfigDir(t)
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "passphrase")
+	store, err := newEncryptedSecretStore()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Set("tenant", "pass"))
+
+	// Another passphrase would make the other passwords unreadable.
+	t.Setenv("ANTITHESIS_CREDENTIALS_PASSPHRASE", "wrong")
+	wrong, err := newEncryptedSecretStore()
+	assert.NoError(t, err)
+	assert.Error(t, wrong.Set("other", "secret"))
+}
@@ -53,13 +53,7 @@ antithesis update
 				return nil
 			}
 
-			var confirm string
-			cmd.Println(WarningStyle.Render(fmt.Sprintf("Do you want to perform the update to version %s?", latest)))
-			cmd.Printf("Only %s will be accepted to approve.\n", ValueStyle.Render("'yes'"))
-			cmd.Printf("Enter a value: ")
-			_, _ = fmt.Scanln(&confirm)
-
-			if strings.ToLower(confirm) != "yes" {
+			if ok, _ := confirm(cmd, fmt.Sprintf("Do you want to perform the update to version %s?", latest)); !ok {
 				return nil
 			}
 			err = updateCLI()
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	// maxPollErrors is how many polls in a row can fail, e.g. because of a
+	// flaky network in CI, before giving up on waiting for a run.
+	maxPollErrors = 5
+
+	spinnerInterval = 100 * time.Millisecond
+)
+
+var (
+	spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+)
+
+// waitForRun polls the status of a run until it is done or the timeout, if
+// any, expires. The last known status is returned along with a timeout error.
+// When interrupted, it offers to cancel the run.
+func waitForRun(cmd *cobra.Command, c HTTPClient, creds credentials, id string, timeout, pollInterval time.Duration, interrupts <-chan os.Signal) (*runStatus, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	p := newProgress(cmd.ErrOrStderr())
+	defer p.clear()
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	var spin <-chan time.Time
+	if p.tty {
+		spinner := time.NewTicker(spinnerInterval)
+		defer spinner.Stop()
+		spin = spinner.C
+	}
+
+	var (
+		status *runStatus
+		errs   int
+	)
+	for {
+		latest, err := fetchRunStatus(c, creds, id)
+		switch {
+		case err == nil:
+			status, errs = latest, 0
+			if status.done() {
+				return status, nil
+			}
+		case errs+1 < maxPollErrors:
+			errs++
+			p.warn(fmt.Sprintf("Warning: failed to get the status of test run %s, retrying: %v", id, err))
+		default:
+			return status, fmt.Errorf("failed to get the status of test run %s: %w", id, err)
+		}
+		p.update(status)
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return status, &exitCodeError{
+						code: exitTimeout,
+						err:  fmt.Errorf("timed out after %s waiting for test run %s", timeout, id),
+					}
+				}
+				return status, ctx.Err()
+			case <-interrupts:
+				p.clear()
+				ok, err := confirmCancel(cmd, id, interrupts)
+				if err != nil {
+					// Nobody answered, so stop waiting but let the run go on.
+					return nil, fmt.Errorf("interrupted while waiting for test run %s, which is still running: cancel it with 'antithesis runs cancel %s'", id, id)
+				}
+				if !ok {
+					p.render()
+					continue
+				}
+				if err := cancelRun(c, creds, id); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("test run %s has been cancelled", id)
+			case <-spin:
+				p.render()
+			case <-poll.C:
+				break wait
+			}
+		}
+	}
+}
+
+// confirmCancel asks whether to cancel a run. Interrupting again instead of
+// answering fails, as does closing stdin.
+func confirmCancel(cmd *cobra.Command, id string, interrupts <-chan os.Signal) (bool, error) {
+	type answer struct {
+		ok  bool
+		err error
+	}
+	answers := make(chan answer, 1)
+	go func() {
+		ok, err := confirm(cmd, fmt.Sprintf("Do you want to cancel test run %s?", id))
+		answers <- answer{ok, err}
+	}()
+	select {
+	case a := <-answers:
+		return a.ok, a.err
+	case <-interrupts:
+		cmd.Println()
+		return false, fmt.Errorf("interrupted")
+	}
+}
+
+// runOutcome turns the final status of a run into the error, and exit code,
+// of 'antithesis run --wait'.
+func runOutcome(status *runStatus) error {
+	switch {
+	case status.State == runCancelled:
+		return fmt.Errorf("test run %s has been cancelled", status.ID)
+	case status.State == runFailed:
+		err := fmt.Errorf("test run %s failed to run", status.ID)
+		if status.Message != "" {
+			err = fmt.Errorf("%w: %s", err, status.Message)
+		}
+		return &exitCodeError{code: exitInfrastructureError, err: err}
+	case status.FailedProperties > 0:
+		return &exitCodeError{
+			code: exitTestFailures,
+			err:  fmt.Errorf("test run %s found %d failing properties", status.ID, status.FailedProperties),
+		}
+	}
+	return nil
+}
+
+// progress reports the status of a run while waiting for it. On a terminal
+// it redraws a single line with a spinner, elsewhere, e.g. in CI logs, it
+// prints a line whenever the state changes.
+type progress struct {
+	w      io.Writer
+	tty    bool
+	frame  int
+	status *runStatus
+	state  string
+}
+
+func newProgress(w io.Writer) *progress {
+	f, ok := w.(*os.File)
+	return &progress{w: w, tty: ok && term.IsTerminal(int(f.Fd()))}
+}
+
+func (p *progress) update(status *runStatus) {
+	if status == nil {
+		return
+	}
+	p.status = status
+	if p.tty {
+		p.render()
+		return
+	}
+	if status.State != p.state {
+		p.state = status.State
+		fmt.Fprintf(p.w, "Test run %s is %s (%s elapsed)\n", status.ID, status.State, status.elapsed(time.Now()))
+	}
+}
+
+func (p *progress) render() {
+	if !p.tty || p.status == nil {
+		return
+	}
+	line := fmt.Sprintf("%s Test run %s is %s, %s elapsed",
+		spinnerFrames[p.frame%len(spinnerFrames)],
+		ValueStyle.Render(p.status.ID),
+		stateStyle(p.status.State).Render(p.status.State),
+		p.status.elapsed(time.Now()))
+	if eta, ok := p.status.eta(); ok {
+		line += SubtleStyle.Render(fmt.Sprintf(" (ETA %s)", eta.Local().Format("3:04PM")))
+	}
+	p.frame++
+	fmt.Fprintf(p.w, "\r\033[K%s", line)
+}
+
+func (p *progress) warn(msg string) {
+	p.clear()
+	fmt.Fprintln(p.w, WarningStyle.Render(msg))
+}
+
+func (p *progress) clear() {
+	if p.tty {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+}
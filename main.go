@@ -9,6 +9,6 @@ import (
 func main() {
 	if err := cli.Main(); err != nil {
 		// The error is logged by the CLI library.
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }